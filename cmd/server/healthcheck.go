@@ -0,0 +1,36 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/ifauzeee/Zee-AI/internal/provider"
+)
+
+func runHealthChecks(ctx context.Context, pool *provider.Pool, interval time.Duration, logger *slog.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		before := make(map[string]bool)
+		for _, b := range pool.Backends() {
+			before[b.URL] = b.Healthy
+		}
+		pool.Refresh(ctx)
+		for _, b := range pool.Backends() {
+			switch {
+			case b.Healthy && !before[b.URL]:
+				logger.Info("ollama backend recovered", "url", b.URL)
+			case !b.Healthy && before[b.URL]:
+				logger.Warn("ollama backend down", "url", b.URL, "error", b.Error)
+			}
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/ifauzeee/Zee-AI/internal/config"
+	"github.com/ifauzeee/Zee-AI/internal/db"
+)
+
+func runJanitor(ctx context.Context, database *db.DB, cfg *config.Config, logger *slog.Logger) {
+	ticker := time.NewTicker(cfg.JanitorInterval)
+	defer ticker.Stop()
+
+	for {
+		n, err := database.PurgeTrash(time.Now().Add(-cfg.TrashRetention))
+		if err != nil {
+			logger.Error("purge trash failed", "error", err)
+		} else if n > 0 {
+			logger.Info("purged trashed conversations", "count", n)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
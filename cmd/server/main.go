@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log/slog"
 	"net/http"
@@ -8,10 +9,12 @@ import (
 	"os/signal"
 	"syscall"
 
+	"github.com/google/uuid"
 	"github.com/ifauzeee/Zee-AI/internal/api"
+	"github.com/ifauzeee/Zee-AI/internal/auth"
 	"github.com/ifauzeee/Zee-AI/internal/config"
 	"github.com/ifauzeee/Zee-AI/internal/db"
-	"github.com/ifauzeee/Zee-AI/internal/ollama"
+	"github.com/ifauzeee/Zee-AI/internal/provider"
 	"github.com/joho/godotenv"
 )
 
@@ -44,11 +47,38 @@ func main() {
 	defer database.Close()
 	logger.Info("database initialized", "path", cfg.DBPath)
 
-	ollamaClient := ollama.New(cfg.OllamaBaseURL)
+	if err := bootstrapAdmin(database, cfg, logger); err != nil {
+		logger.Error("failed to bootstrap admin user", "error", err)
+		os.Exit(1)
+	}
+
+	ctx := context.Background()
+	pool := provider.NewPool(cfg.OllamaHosts(), cfg.OllamaBalance)
+	pool.Refresh(ctx)
+	for _, b := range pool.Backends() {
+		if b.Healthy {
+			logger.Info("ollama backend connected", "url", b.URL, "models", len(b.Models))
+		} else {
+			logger.Warn("ollama backend is not reachable", "url", b.URL, "error", b.Error)
+		}
+	}
 
-	if ollamaClient.IsHealthy() {
-		logger.Info("ollama connected", "url", cfg.OllamaBaseURL)
-		models, err := ollamaClient.ListModels()
+	providers := provider.NewRouter("ollama", pool)
+	var installed []string
+	for _, b := range pool.Backends() {
+		installed = append(installed, b.Models...)
+	}
+	for _, p := range cfg.Providers {
+		if p.Name == "ollama" || provider.Shadowed(p.Name, installed) {
+			logger.Error("provider name collides with an ollama model namespace", "name", p.Name)
+			os.Exit(1)
+		}
+		providers.Register(p.Name, provider.NewOpenAI(p.BaseURL, p.APIKey))
+		logger.Info("provider registered", "name", p.Name, "url", p.BaseURL)
+	}
+
+	if providers.IsHealthy(ctx) {
+		models, err := providers.ListModels(ctx)
 		if err == nil {
 			logger.Info("available models", "count", len(models))
 			for _, m := range models {
@@ -60,7 +90,12 @@ func main() {
 		logger.Warn("start Ollama first: ollama serve")
 	}
 
-	handler := api.NewHandler(database, ollamaClient, cfg, logger)
+	backgroundCtx, stopBackground := context.WithCancel(ctx)
+	defer stopBackground()
+	go runJanitor(backgroundCtx, database, cfg, logger)
+	go runHealthChecks(backgroundCtx, pool, cfg.OllamaHealthInterval, logger)
+
+	handler := api.NewHandler(database, providers, cfg, logger)
 	router := api.NewRouter(handler)
 
 	addr := fmt.Sprintf("%s:%s", cfg.Host, cfg.Port)
@@ -86,3 +121,57 @@ func main() {
 		os.Exit(1)
 	}
 }
+
+func bootstrapAdmin(database *db.DB, cfg *config.Config, logger *slog.Logger) error {
+	count, err := database.CountUsers()
+	if err != nil {
+		return err
+	}
+
+	if count == 0 {
+		password := cfg.AdminPassword
+		if password == "" {
+			password, err = auth.NewToken()
+			if err != nil {
+				return err
+			}
+			password = password[:16]
+			logger.Warn("ADMIN_PASSWORD is not set, generated a password for the admin user (printed once to stderr)",
+				"username", cfg.AdminUsername,
+			)
+			fmt.Fprintf(os.Stderr, "\nGenerated password for admin user %q: %s\nIt is not stored anywhere else; change it after signing in.\n\n", cfg.AdminUsername, password)
+		}
+
+		hash, err := auth.HashPassword(password)
+		if err != nil {
+			return err
+		}
+		admin := &db.User{
+			ID:           uuid.New().String(),
+			Username:     cfg.AdminUsername,
+			PasswordHash: hash,
+			Role:         db.RoleAdmin,
+		}
+		if err := database.CreateUser(admin); err != nil {
+			return err
+		}
+		logger.Info("created admin user", "username", admin.Username)
+	}
+
+	admin, err := database.GetUserByUsername(cfg.AdminUsername)
+	if err != nil {
+		if cfg.APISecretKey != "" {
+			return fmt.Errorf("admin user %q not found: %w", cfg.AdminUsername, err)
+		}
+		return nil
+	}
+
+	n, err := database.AssignOrphanConversations(admin.ID)
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		logger.Info("assigned existing conversations to admin", "username", admin.Username, "count", n)
+	}
+	return nil
+}
@@ -0,0 +1,202 @@
+package api
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/ifauzeee/Zee-AI/internal/db"
+)
+
+var attachmentTypes = map[string]string{
+	"image/png":  ".png",
+	"image/jpeg": ".jpg",
+	"image/gif":  ".gif",
+	"image/webp": ".webp",
+}
+
+type upload struct {
+	filename string
+	data     []byte
+}
+
+func (h *Handler) decodeChatRequest(w http.ResponseWriter, r *http.Request) (*ChatAPIRequest, []upload, bool) {
+	maxBody := int64(h.cfg.MaxAttachments)*int64(h.cfg.MaxAttachmentMB)<<20*4/3 + 1<<20
+	r.Body = http.MaxBytesReader(w, r.Body, maxBody)
+
+	var req ChatAPIRequest
+	var uploads []upload
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == "multipart/form-data" {
+		if err := r.ParseMultipartForm(32 << 20); err != nil {
+			writeBodyError(w, err)
+			return nil, nil, false
+		}
+		defer r.MultipartForm.RemoveAll()
+		if err := chatRequestFromForm(r, &req); err != nil {
+			writeError(w, http.StatusBadRequest, "Invalid request body: "+err.Error())
+			return nil, nil, false
+		}
+		for _, fh := range r.MultipartForm.File["images"] {
+			f, err := fh.Open()
+			if err != nil {
+				writeError(w, http.StatusBadRequest, "Invalid upload")
+				return nil, nil, false
+			}
+			data, err := io.ReadAll(f)
+			f.Close()
+			if err != nil {
+				writeBodyError(w, err)
+				return nil, nil, false
+			}
+			uploads = append(uploads, upload{filename: fh.Filename, data: data})
+		}
+	} else if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeBodyError(w, err)
+		return nil, nil, false
+	}
+
+	for i, img := range req.Images {
+		data, err := decodeImage(img)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("Image %d is not valid base64", i+1))
+			return nil, nil, false
+		}
+		uploads = append(uploads, upload{data: data})
+	}
+	return &req, uploads, true
+}
+
+func chatRequestFromForm(r *http.Request, req *ChatAPIRequest) error {
+	if payload := r.FormValue("request"); payload != "" {
+		return json.Unmarshal([]byte(payload), req)
+	}
+	req.ConversationID = r.FormValue("conversation_id")
+	req.Model = r.FormValue("model")
+	req.Message = r.FormValue("message")
+	req.SystemPrompt = r.FormValue("system_prompt")
+	req.Tools = r.MultipartForm.Value["tools"]
+	req.Format = formJSON(r.FormValue("format"))
+	if retries := r.FormValue("format_retries"); retries != "" {
+		n, err := strconv.Atoi(retries)
+		if err != nil {
+			return fmt.Errorf("format_retries: %w", err)
+		}
+		req.FormatRetries = n
+	}
+	req.Options = json.RawMessage(r.FormValue("options"))
+	req.KeepAlive = formJSON(r.FormValue("keep_alive"))
+	return nil
+}
+
+func formJSON(v string) json.RawMessage {
+	if v == "" || json.Valid([]byte(v)) {
+		return json.RawMessage(v)
+	}
+	data, _ := json.Marshal(v)
+	return data
+}
+
+func writeBodyError(w http.ResponseWriter, err error) {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		writeError(w, http.StatusRequestEntityTooLarge, "Request body too large")
+		return
+	}
+	writeError(w, http.StatusBadRequest, "Invalid request body")
+}
+
+func decodeImage(s string) ([]byte, error) {
+	if strings.HasPrefix(s, "data:") {
+		_, payload, ok := strings.Cut(s, ",")
+		if !ok {
+			return nil, errors.New("malformed data URL")
+		}
+		s = payload
+	}
+	s = strings.TrimSpace(s)
+	if data, err := base64.StdEncoding.DecodeString(s); err == nil {
+		return data, nil
+	}
+	return base64.RawStdEncoding.DecodeString(strings.TrimRight(s, "="))
+}
+
+func (h *Handler) buildAttachments(w http.ResponseWriter, userID string, uploads []upload) ([]db.Attachment, bool) {
+	if len(uploads) == 0 {
+		return nil, true
+	}
+	if len(uploads) > h.cfg.MaxAttachments {
+		if h.cfg.MaxAttachments == 0 {
+			writeError(w, http.StatusBadRequest, "Image uploads are disabled")
+		} else {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("At most %d images can be attached to a message", h.cfg.MaxAttachments))
+		}
+		return nil, false
+	}
+
+	maxSize := int64(h.cfg.MaxAttachmentMB) << 20
+	attachments := make([]db.Attachment, 0, len(uploads))
+	for i, u := range uploads {
+		if len(u.data) == 0 {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("Image %d is empty", i+1))
+			return nil, false
+		}
+		if int64(len(u.data)) > maxSize {
+			writeError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("Image %d is larger than %d MB", i+1, h.cfg.MaxAttachmentMB))
+			return nil, false
+		}
+		mimeType := http.DetectContentType(u.data)
+		ext, ok := attachmentTypes[mimeType]
+		if !ok {
+			writeError(w, http.StatusUnsupportedMediaType, fmt.Sprintf("Image %d has unsupported type %s; use PNG, JPEG, GIF or WebP", i+1, mimeType))
+			return nil, false
+		}
+		filename := u.filename
+		if filename == "" {
+			filename = "image-" + strconv.Itoa(i+1) + ext
+		}
+		attachments = append(attachments, db.Attachment{
+			ID:       uuid.New().String(),
+			UserID:   userID,
+			Filename: filename,
+			MimeType: mimeType,
+			Data:     u.data,
+		})
+	}
+	return attachments, true
+}
+
+func copyAttachments(userID string, src []db.Attachment) []db.Attachment {
+	out := make([]db.Attachment, 0, len(src))
+	for _, a := range src {
+		out = append(out, db.Attachment{
+			ID:       uuid.New().String(),
+			UserID:   userID,
+			Filename: a.Filename,
+			MimeType: a.MimeType,
+			Data:     a.Data,
+		})
+	}
+	return out
+}
+
+func (h *Handler) GetAttachment(w http.ResponseWriter, r *http.Request) {
+	a, err := h.db.GetAttachment(r.PathValue("id"), currentUser(r).ID)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "Attachment not found")
+		return
+	}
+	w.Header().Set("Content-Type", a.MimeType)
+	w.Header().Set("Content-Length", strconv.Itoa(len(a.Data)))
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("inline", map[string]string{"filename": a.Filename}))
+	w.Header().Set("Cache-Control", "private, max-age=86400")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Write(a.Data)
+}
@@ -0,0 +1,122 @@
+package api
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"image"
+	"image/png"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func testPNG(t *testing.T) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 2, 2))); err != nil {
+		t.Fatalf("encode png: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestChatImageAttachments(t *testing.T) {
+	fake := newFakeOllama(t, "A tiny image")
+	router, _ := newTestRouterWithOllama(t, fake.URL)
+	headers := map[string]string{"Authorization": "Bearer s3cret"}
+	img := testPNG(t)
+	encoded := base64.StdEncoding.EncodeToString(img)
+
+	rec := doRequest(t, router, http.MethodPost, "/api/chat", headers, map[string]interface{}{
+		"model":   "llava",
+		"message": "what is this?",
+		"images":  []string{"data:image/png;base64," + encoded},
+	})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("chat = %d: %s", rec.Code, rec.Body)
+	}
+	if msgs := fake.lastChat(t, true).Messages; len(msgs) != 1 || len(msgs[0].Images) != 1 || msgs[0].Images[0] != encoded {
+		t.Fatalf("ollama messages = %+v", msgs)
+	}
+	var init struct {
+		ConversationID string `json:"conversation_id"`
+	}
+	data, _, _ := strings.Cut(strings.TrimPrefix(rec.Body.String(), "data: "), "\n")
+	json.Unmarshal([]byte(data), &init)
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	mw.WriteField("conversation_id", init.ConversationID)
+	mw.WriteField("model", "llava")
+	mw.WriteField("message", "and this one?")
+	part, _ := mw.CreateFormFile("images", "second.png")
+	part.Write(img)
+	mw.Close()
+	req := httptest.NewRequest(http.MethodPost, "/api/chat", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	req.Header.Set("Authorization", "Bearer s3cret")
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("multipart chat = %d: %s", rec.Code, rec.Body)
+	}
+	if msgs := fake.lastChat(t, true).Messages; len(msgs[len(msgs)-1].Images) != 1 {
+		t.Fatalf("ollama messages = %+v", msgs)
+	}
+
+	rec = doRequest(t, router, http.MethodPost, "/api/conversations/"+init.ConversationID+"/regenerate", headers, nil)
+	if msgs := fake.lastChat(t, true).Messages; rec.Code != http.StatusOK || len(msgs[len(msgs)-1].Images) != 1 || msgs[len(msgs)-1].Images[0] != encoded {
+		t.Fatalf("regenerate = %d, messages %+v", rec.Code, msgs)
+	}
+
+	rec = doRequest(t, router, http.MethodGet, "/api/conversations/"+init.ConversationID, headers, nil)
+	var convo struct {
+		Messages []struct {
+			Role        string `json:"role"`
+			Attachments []struct {
+				ID       string `json:"id"`
+				Filename string `json:"filename"`
+				MimeType string `json:"mime_type"`
+				Size     int    `json:"size"`
+			} `json:"attachments"`
+		} `json:"messages"`
+	}
+	json.Unmarshal(rec.Body.Bytes(), &convo)
+	if len(convo.Messages) != 4 || len(convo.Messages[2].Attachments) != 1 {
+		t.Fatalf("conversation = %s", rec.Body)
+	}
+	a := convo.Messages[2].Attachments[0]
+	if a.Filename != "second.png" || a.MimeType != "image/png" || a.Size != len(img) || strings.Contains(rec.Body.String(), encoded) {
+		t.Fatalf("attachment = %+v", a)
+	}
+
+	rec = doRequest(t, router, http.MethodGet, "/api/attachments/"+a.ID, headers, nil)
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "image/png" || !bytes.Equal(rec.Body.Bytes(), img) {
+		t.Fatalf("download = %d %s", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	alice := map[string]string{"Authorization": "Bearer " + login(t, router, "alice")}
+	if rec := doRequest(t, router, http.MethodGet, "/api/attachments/"+a.ID, alice, nil); rec.Code != http.StatusNotFound {
+		t.Fatalf("other user download = %d, want 404", rec.Code)
+	}
+
+	for _, tc := range []struct {
+		images []string
+		status int
+	}{
+		{[]string{base64.StdEncoding.EncodeToString([]byte("just text"))}, http.StatusUnsupportedMediaType},
+		{[]string{encoded, encoded, encoded}, http.StatusBadRequest},
+		{[]string{"not base64!"}, http.StatusBadRequest},
+		{[]string{base64.StdEncoding.EncodeToString(append(img, make([]byte, 1<<20)...))}, http.StatusRequestEntityTooLarge},
+	} {
+		rec := doRequest(t, router, http.MethodPost, "/api/chat", headers, map[string]interface{}{
+			"model":   "llava",
+			"message": "hi",
+			"images":  tc.images,
+		})
+		if rec.Code != tc.status {
+			t.Errorf("images %d: status = %d, want %d: %s", len(tc.images), rec.Code, tc.status, rec.Body)
+		}
+	}
+}
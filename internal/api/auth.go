@@ -0,0 +1,286 @@
+package api
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ifauzeee/Zee-AI/internal/auth"
+	"github.com/ifauzeee/Zee-AI/internal/db"
+)
+
+type contextKey int
+
+const principalContextKey contextKey = iota
+
+type principal struct {
+	user        *db.User
+	sessionHash string
+	token       *db.APIToken
+}
+
+var errInvalidCredentials = errors.New("invalid credentials")
+
+var dummyPasswordHash = sync.OnceValue(func() string {
+	hash, _ := auth.HashPassword("not a real password")
+	return hash
+})
+
+func (h *Handler) authMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if isPublicRoute(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		credential := credentialFromRequest(r)
+		if credential == "" {
+			unauthorized(w, r, "Missing credentials")
+			return
+		}
+
+		p, err := h.authenticate(credential)
+		if err != nil {
+			if !errors.Is(err, errInvalidCredentials) {
+				h.logger.Error("authenticate request failed", "error", err)
+			}
+			unauthorized(w, r, "Invalid credentials")
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), principalContextKey, p)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func (h *Handler) authenticate(credential string) (*principal, error) {
+	if h.cfg.APISecretKey != "" && subtle.ConstantTimeCompare([]byte(credential), []byte(h.cfg.APISecretKey)) == 1 {
+		user, err := h.db.GetUserByUsername(h.cfg.AdminUsername)
+		if err != nil {
+			return nil, err
+		}
+		return &principal{user: user}, nil
+	}
+
+	tokenHash := auth.HashToken(credential)
+
+	if strings.HasPrefix(credential, auth.APITokenPrefix) {
+		token, err := h.db.GetAPITokenByHash(tokenHash)
+		if errors.Is(err, db.ErrNotFound) {
+			return nil, errInvalidCredentials
+		}
+		if err != nil {
+			return nil, err
+		}
+		if !token.Active(time.Now()) {
+			return nil, errInvalidCredentials
+		}
+		user, err := h.db.GetUser(token.UserID)
+		if err != nil {
+			return nil, err
+		}
+		h.db.TouchAPIToken(token.ID)
+		return &principal{user: user, token: token}, nil
+	}
+
+	user, err := h.db.GetSessionUser(tokenHash)
+	if errors.Is(err, db.ErrNotFound) {
+		return nil, errInvalidCredentials
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &principal{user: user, sessionHash: tokenHash}, nil
+}
+
+func requireScope(scope string, next http.HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p := currentPrincipal(r)
+		if p == nil {
+			unauthorized(w, r, "Missing credentials")
+			return
+		}
+		if min := scopeRoles[scope]; !p.user.Role.AtLeast(min) {
+			forbidden(w, r, fmt.Sprintf("This action requires the %s role", min))
+			return
+		}
+		if p.token != nil && !p.token.HasScope(scope) {
+			forbidden(w, r, fmt.Sprintf("API token is missing the %s scope", scope))
+			return
+		}
+		next(w, r)
+	})
+}
+
+func requireInteractive(next http.HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if p := currentPrincipal(r); p != nil && p.token != nil {
+			forbidden(w, r, "This action is not available to API tokens")
+			return
+		}
+		next(w, r)
+	})
+}
+
+func isPublicRoute(r *http.Request) bool {
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == "/api/health":
+		return true
+	case r.Method == http.MethodPost && r.URL.Path == "/api/auth/login":
+		return true
+	case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/api/shared/"):
+		return true
+	}
+	return false
+}
+
+func credentialFromRequest(r *http.Request) string {
+	if header := r.Header.Get("Authorization"); header != "" {
+		scheme, token, ok := strings.Cut(header, " ")
+		if ok && strings.EqualFold(scheme, "Bearer") {
+			return strings.TrimSpace(token)
+		}
+	}
+	return strings.TrimSpace(r.Header.Get("X-API-Key"))
+}
+
+func unauthorized(w http.ResponseWriter, r *http.Request, message string) {
+	w.Header().Set("WWW-Authenticate", `Bearer realm="zee-ai"`)
+	writeAuthError(w, r, http.StatusUnauthorized, message)
+}
+
+func forbidden(w http.ResponseWriter, r *http.Request, message string) {
+	writeAuthError(w, r, http.StatusForbidden, message)
+}
+
+func writeAuthError(w http.ResponseWriter, r *http.Request, status int, message string) {
+	if strings.HasPrefix(r.URL.Path, "/v1/") {
+		writeOpenAIError(w, status, "invalid_request_error", message)
+		return
+	}
+	writeError(w, status, message)
+}
+
+func currentPrincipal(r *http.Request) *principal {
+	p, _ := r.Context().Value(principalContextKey).(*principal)
+	return p
+}
+
+func currentUser(r *http.Request) *db.User {
+	if p := currentPrincipal(r); p != nil {
+		return p.user
+	}
+	return nil
+}
+
+func (h *Handler) Login(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Username string `json:"username"`
+		Password string `json:"password"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Username == "" || req.Password == "" {
+		writeError(w, http.StatusBadRequest, "Username and password are required")
+		return
+	}
+
+	user, err := h.db.GetUserByUsername(req.Username)
+	if err != nil && !errors.Is(err, db.ErrNotFound) {
+		h.logger.Error("lookup user failed", "error", err)
+		writeError(w, http.StatusInternalServerError, "Failed to log in")
+		return
+	}
+	if user == nil {
+		auth.CheckPassword(dummyPasswordHash(), req.Password)
+		writeError(w, http.StatusUnauthorized, "Invalid username or password")
+		return
+	}
+	ok, err := auth.CheckPassword(user.PasswordHash, req.Password)
+	if err != nil {
+		h.logger.Error("check password failed", "user", user.ID, "error", err)
+	}
+	if !ok {
+		writeError(w, http.StatusUnauthorized, "Invalid username or password")
+		return
+	}
+
+	token, err := auth.NewToken()
+	if err != nil {
+		h.logger.Error("create session token failed", "error", err)
+		writeError(w, http.StatusInternalServerError, "Failed to log in")
+		return
+	}
+	now := time.Now()
+	session := &db.Session{
+		TokenHash: auth.HashToken(token),
+		UserID:    user.ID,
+		CreatedAt: now,
+		ExpiresAt: now.Add(h.cfg.SessionTTL),
+	}
+	h.db.DeleteExpiredSessions(user.ID)
+	if err := h.db.CreateSession(session); err != nil {
+		h.logger.Error("create session failed", "error", err)
+		writeError(w, http.StatusInternalServerError, "Failed to log in")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"token":      token,
+		"expires_at": session.ExpiresAt,
+		"user":       user,
+	})
+}
+
+func (h *Handler) Logout(w http.ResponseWriter, r *http.Request) {
+	sessionHash := currentPrincipal(r).sessionHash
+	if sessionHash == "" {
+		writeError(w, http.StatusBadRequest, "Not authenticated with a session token")
+		return
+	}
+	if err := h.db.DeleteSession(sessionHash); err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to log out")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"status": "logged_out"})
+}
+
+func (h *Handler) Me(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, currentUser(r))
+}
+
+func (h *Handler) ChangePassword(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		CurrentPassword string `json:"current_password"`
+		NewPassword     string `json:"new_password"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if len(req.NewPassword) < minPasswordLength {
+		writeError(w, http.StatusBadRequest, "New password is too short")
+		return
+	}
+
+	user := currentUser(r)
+	if ok, _ := auth.CheckPassword(user.PasswordHash, req.CurrentPassword); !ok {
+		writeError(w, http.StatusUnauthorized, "Current password is incorrect")
+		return
+	}
+
+	hash, err := auth.HashPassword(req.NewPassword)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to update password")
+		return
+	}
+	if err := h.db.UpdateUserPassword(user.ID, hash); err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to update password")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"status": "updated"})
+}
@@ -0,0 +1,296 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ifauzeee/Zee-AI/internal/auth"
+	"github.com/ifauzeee/Zee-AI/internal/config"
+	"github.com/ifauzeee/Zee-AI/internal/db"
+	"github.com/ifauzeee/Zee-AI/internal/provider"
+)
+
+const testPassword = "correct-horse"
+
+func newTestRouter(t *testing.T) (http.Handler, *db.DB) {
+	t.Helper()
+	return newTestRouterWithOllama(t, "http://127.0.0.1:0")
+}
+
+func newTestRouterWithOllama(t *testing.T, ollamaURL string) (http.Handler, *db.DB) {
+	t.Helper()
+	return newTestRouterWithProvider(t, provider.NewRouter("ollama", provider.NewPool([]string{ollamaURL}, provider.BalanceLeastBusy)))
+}
+
+func newTestRouterWithProvider(t *testing.T, llm provider.Provider) (http.Handler, *db.DB) {
+	t.Helper()
+
+	database, err := db.New(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	t.Cleanup(func() { database.Close() })
+
+	hash, err := auth.HashPassword(testPassword)
+	if err != nil {
+		t.Fatalf("hash password: %v", err)
+	}
+	for _, u := range []*db.User{
+		{ID: "admin-id", Username: "admin", PasswordHash: hash, Role: db.RoleAdmin},
+		{ID: "alice-id", Username: "alice", PasswordHash: hash, Role: db.RoleMember},
+		{ID: "bob-id", Username: "bob", PasswordHash: hash, Role: db.RoleReadOnly},
+	} {
+		if err := database.CreateUser(u); err != nil {
+			t.Fatalf("create user: %v", err)
+		}
+	}
+
+	cfg := &config.Config{
+		APISecretKey:  "s3cret",
+		AdminUsername: "admin",
+		SessionTTL:    time.Hour,
+
+		MaxAttachments:  2,
+		MaxAttachmentMB: 1,
+	}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	h := NewHandler(database, llm, cfg, logger)
+	return NewRouter(h), database
+}
+
+func doRequest(t *testing.T, router http.Handler, method, path string, headers map[string]string, body interface{}) *httptest.ResponseRecorder {
+	t.Helper()
+
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			t.Fatalf("marshal body: %v", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+	req := httptest.NewRequest(method, path, reader)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+func login(t *testing.T, router http.Handler, username string) string {
+	t.Helper()
+
+	rec := doRequest(t, router, http.MethodPost, "/api/auth/login", nil, map[string]string{
+		"username": username,
+		"password": testPassword,
+	})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("login %s: status = %d, body = %s", username, rec.Code, rec.Body)
+	}
+	var resp struct {
+		Token string `json:"token"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil || resp.Token == "" {
+		t.Fatalf("login %s: missing token (err %v)", username, err)
+	}
+	return resp.Token
+}
+
+func TestAuthMiddleware(t *testing.T) {
+	router, _ := newTestRouter(t)
+
+	tests := []struct {
+		name    string
+		method  string
+		path    string
+		headers map[string]string
+		want    int
+	}{
+		{"health is public", http.MethodGet, "/api/health", nil, http.StatusOK},
+		{"missing credentials", http.MethodGet, "/api/conversations", nil, http.StatusUnauthorized},
+		{"valid bearer api key", http.MethodGet, "/api/conversations", map[string]string{"Authorization": "Bearer s3cret"}, http.StatusOK},
+		{"lowercase bearer scheme", http.MethodGet, "/api/stats", map[string]string{"Authorization": "bearer s3cret"}, http.StatusOK},
+		{"valid api key header", http.MethodGet, "/api/auth/me", map[string]string{"X-API-Key": "s3cret"}, http.StatusOK},
+		{"wrong bearer token", http.MethodGet, "/api/conversations", map[string]string{"Authorization": "Bearer nope"}, http.StatusUnauthorized},
+		{"wrong api key header", http.MethodPost, "/api/models/pull", map[string]string{"X-API-Key": "s3cre"}, http.StatusUnauthorized},
+		{"non-bearer scheme", http.MethodGet, "/api/stats", map[string]string{"Authorization": "Basic s3cret"}, http.StatusUnauthorized},
+		{"health is only public for GET", http.MethodPost, "/api/health", nil, http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := doRequest(t, router, tt.method, tt.path, tt.headers, nil)
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.want)
+			}
+			if tt.want != http.StatusUnauthorized {
+				return
+			}
+			if got := rec.Header().Get("Content-Type"); got != "application/json" {
+				t.Errorf("Content-Type = %q, want application/json", got)
+			}
+			var body map[string]string
+			if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+				t.Fatalf("decode error body: %v", err)
+			}
+			if body["error"] == "" {
+				t.Errorf("expected error message in body, got %v", body)
+			}
+		})
+	}
+}
+
+func TestLoginLogout(t *testing.T) {
+	router, _ := newTestRouter(t)
+
+	rec := doRequest(t, router, http.MethodPost, "/api/auth/login", nil, map[string]string{
+		"username": "alice",
+		"password": "wrong-password",
+	})
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("login with wrong password: status = %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+	rec = doRequest(t, router, http.MethodPost, "/api/auth/login", nil, map[string]string{
+		"username": "nobody",
+		"password": "wrong-password",
+	})
+	if rec.Code != http.StatusUnauthorized || !strings.Contains(rec.Body.String(), "Invalid username or password") {
+		t.Fatalf("login as unknown user: status = %d, body = %s", rec.Code, rec.Body)
+	}
+	if ok, err := auth.CheckPassword(dummyPasswordHash(), "wrong-password"); ok || err != nil {
+		t.Fatalf("dummy hash check = %v, %v, want a full, failing comparison", ok, err)
+	}
+
+	token := login(t, router, "alice")
+	bearer := map[string]string{"Authorization": "Bearer " + token}
+
+	rec = doRequest(t, router, http.MethodGet, "/api/auth/me", bearer, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("me: status = %d", rec.Code)
+	}
+	var me db.User
+	json.NewDecoder(rec.Body).Decode(&me)
+	if me.Username != "alice" {
+		t.Fatalf("me: username = %q, want alice", me.Username)
+	}
+
+	if rec := doRequest(t, router, http.MethodPost, "/api/auth/logout", bearer, nil); rec.Code != http.StatusOK {
+		t.Fatalf("logout: status = %d", rec.Code)
+	}
+	if rec := doRequest(t, router, http.MethodGet, "/api/auth/me", bearer, nil); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("me after logout: status = %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+}
+
+func TestConversationsAreScopedToUser(t *testing.T) {
+	router, _ := newTestRouter(t)
+
+	alice := map[string]string{"Authorization": "Bearer " + login(t, router, "alice")}
+	admin := map[string]string{"X-API-Key": "s3cret"}
+
+	rec := doRequest(t, router, http.MethodPost, "/api/conversations", alice, map[string]string{"title": "Alice's chat"})
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("create conversation: status = %d", rec.Code)
+	}
+	var convo db.Conversation
+	json.NewDecoder(rec.Body).Decode(&convo)
+
+	if rec := doRequest(t, router, http.MethodGet, "/api/conversations/"+convo.ID, admin, nil); rec.Code != http.StatusNotFound {
+		t.Fatalf("other user get conversation: status = %d, want %d", rec.Code, http.StatusNotFound)
+	}
+	if rec := doRequest(t, router, http.MethodDelete, "/api/conversations/"+convo.ID, admin, nil); rec.Code != http.StatusNotFound {
+		t.Fatalf("other user delete conversation: status = %d, want %d", rec.Code, http.StatusNotFound)
+	}
+
+	rec = doRequest(t, router, http.MethodGet, "/api/conversations", admin, nil)
+	var list struct {
+		Conversations []db.Conversation `json:"conversations"`
+	}
+	json.NewDecoder(rec.Body).Decode(&list)
+	if len(list.Conversations) != 0 {
+		t.Fatalf("other user sees %d conversations, want 0", len(list.Conversations))
+	}
+
+	if rec := doRequest(t, router, http.MethodGet, "/api/conversations/"+convo.ID, alice, nil); rec.Code != http.StatusOK {
+		t.Fatalf("owner get conversation: status = %d, want %d", rec.Code, http.StatusOK)
+	}
+}
+
+func TestRoleEnforcement(t *testing.T) {
+	router, _ := newTestRouter(t)
+
+	bearer := func(username string) map[string]string {
+		return map[string]string{"Authorization": "Bearer " + login(t, router, username)}
+	}
+	admin, member, readonly := bearer("admin"), bearer("alice"), bearer("bob")
+
+	tests := []struct {
+		name    string
+		method  string
+		path    string
+		headers map[string]string
+		body    interface{}
+		want    int
+	}{
+		{"member cannot pull models", http.MethodPost, "/api/models/pull", member, map[string]string{"name": "llama3"}, http.StatusForbidden},
+		{"readonly cannot pull models", http.MethodPost, "/api/models/pull", readonly, map[string]string{"name": "llama3"}, http.StatusForbidden},
+		{"admin passes role check on pull", http.MethodPost, "/api/models/pull", admin, map[string]string{}, http.StatusBadRequest},
+		{"member cannot delete models", http.MethodDelete, "/api/models/llama3", member, nil, http.StatusForbidden},
+		{"member cannot list users", http.MethodGet, "/api/users", member, nil, http.StatusForbidden},
+		{"admin can list users", http.MethodGet, "/api/users", admin, nil, http.StatusOK},
+		{"readonly can list conversations", http.MethodGet, "/api/conversations", readonly, nil, http.StatusOK},
+		{"readonly cannot create conversations", http.MethodPost, "/api/conversations", readonly, map[string]string{}, http.StatusForbidden},
+		{"readonly cannot chat", http.MethodPost, "/api/chat", readonly, map[string]string{"model": "m", "message": "hi"}, http.StatusForbidden},
+		{"member can create conversations", http.MethodPost, "/api/conversations", member, map[string]string{}, http.StatusCreated},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := doRequest(t, router, tt.method, tt.path, tt.headers, tt.body)
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d (body %s)", rec.Code, tt.want, rec.Body)
+			}
+		})
+	}
+
+	rec := doRequest(t, router, http.MethodPatch, "/api/users/bob-id", admin, map[string]string{"role": "member"})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("promote bob: status = %d", rec.Code)
+	}
+	if rec := doRequest(t, router, http.MethodPost, "/api/conversations", readonly, map[string]string{}); rec.Code != http.StatusCreated {
+		t.Fatalf("promoted user create conversation: status = %d, want %d", rec.Code, http.StatusCreated)
+	}
+}
+
+func TestAdminAccountsProtected(t *testing.T) {
+	router, database := newTestRouter(t)
+	key := map[string]string{"Authorization": "Bearer s3cret"}
+
+	if rec := doRequest(t, router, http.MethodPatch, "/api/users/alice-id", key, map[string]string{"role": "admin"}); rec.Code != http.StatusOK {
+		t.Fatalf("promote alice = %d: %s", rec.Code, rec.Body)
+	}
+	alice := map[string]string{"Authorization": "Bearer " + login(t, router, "alice")}
+
+	if rec := doRequest(t, router, http.MethodPatch, "/api/users/admin-id", alice, map[string]string{"role": "member"}); rec.Code != http.StatusBadRequest {
+		t.Fatalf("demote the key account = %d, want 400", rec.Code)
+	}
+	if rec := doRequest(t, router, http.MethodDelete, "/api/users/admin-id", alice, nil); rec.Code != http.StatusBadRequest {
+		t.Fatalf("delete the key account = %d, want 400", rec.Code)
+	}
+	if u, err := database.GetUser("admin-id"); err != nil || u.Role != db.RoleAdmin {
+		t.Fatalf("key account = %+v, %v", u, err)
+	}
+
+	if rec := doRequest(t, router, http.MethodDelete, "/api/users/alice-id", key, nil); rec.Code != http.StatusOK {
+		t.Fatalf("delete the second admin = %d: %s", rec.Code, rec.Body)
+	}
+}
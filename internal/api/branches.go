@@ -0,0 +1,200 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ifauzeee/Zee-AI/internal/db"
+)
+
+func (h *Handler) EditMessage(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Content   string          `json:"content"`
+		Model     string          `json:"model,omitempty"`
+		Options   json.RawMessage `json:"options,omitempty"`
+		KeepAlive json.RawMessage `json:"keep_alive,omitempty"`
+		Tools     []string        `json:"tools,omitempty"`
+
+		Format        json.RawMessage `json:"format,omitempty"`
+		FormatRetries int             `json:"format_retries,omitempty"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if strings.TrimSpace(req.Content) == "" {
+		writeError(w, http.StatusBadRequest, "Content is required")
+		return
+	}
+	toolDefs, ok := h.toolDefinitions(w, req.Tools)
+	if !ok {
+		return
+	}
+	format, ok := h.outputFormat(w, req.Format, req.FormatRetries)
+	if !ok {
+		return
+	}
+	options, keepAlive, ok := h.generationOptions(w, req.Options, req.KeepAlive)
+	if !ok {
+		return
+	}
+
+	id := r.PathValue("id")
+	userID := currentUser(r).ID
+	convo, err := h.db.GetConversation(id, userID)
+	if err != nil || convo.DeletedAt != nil {
+		writeError(w, http.StatusNotFound, "Conversation not found")
+		return
+	}
+
+	original, err := h.db.GetMessage(r.PathValue("messageId"), id, userID)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "Message not found")
+		return
+	}
+	if original.Role != "user" {
+		writeError(w, http.StatusBadRequest, "Only user messages can be edited")
+		return
+	}
+
+	ctx, finish, ok := h.generations.start(r.Context(), id)
+	if !ok {
+		writeError(w, http.StatusConflict, "A response is already being generated for this conversation")
+		return
+	}
+	defer finish()
+
+	settings := h.conversationSettings(id)
+	model := req.Model
+	if model == "" {
+		fallback := convo.Model
+		if settings != nil && settings.Model != "" {
+			fallback = settings.Model
+		}
+		model = h.branchModel(id, userID, original.ID, fallback)
+	}
+	if model == "" {
+		writeError(w, http.StatusBadRequest, "Model is required")
+		return
+	}
+
+	originals := []db.Message{*original}
+	if err := h.db.LoadAttachments(originals, true); err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to load attachments")
+		return
+	}
+
+	params := replyParams{
+		conversationID: id,
+		model:          model,
+		options:        options,
+		keepAlive:      keepAlive,
+		tools:          toolDefs,
+		format:         format,
+	}
+	params.applySettings(settings)
+
+	edited := &db.Message{
+		ID:              uuid.New().String(),
+		ConversationID:  id,
+		ParentID:        original.ParentID,
+		Role:            "user",
+		Content:         req.Content,
+		Attachments:     copyAttachments(userID, originals[0].Attachments),
+		SettingsVersion: params.settingsVersion,
+		CreatedAt:       time.Now(),
+	}
+	if err := h.db.CreateMessage(edited); err != nil {
+		h.logger.Error("save edited message failed", "error", err)
+		writeError(w, http.StatusInternalServerError, "Failed to save message")
+		return
+	}
+
+	history, err := h.db.GetBranch(id, userID, edited.ID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to get messages")
+		return
+	}
+
+	params.history = history
+	h.streamReply(ctx, w, r, params)
+}
+
+func (h *Handler) branchModel(conversationID, userID, messageID, fallback string) string {
+	leaf, err := h.db.LatestLeaf(conversationID, messageID)
+	if err != nil {
+		return fallback
+	}
+	branch, err := h.db.GetBranch(conversationID, userID, leaf)
+	if err != nil {
+		return fallback
+	}
+	for i := len(branch) - 1; i >= 0; i-- {
+		if branch[i].Role == "assistant" && branch[i].Model != "" {
+			return branch[i].Model
+		}
+	}
+	return fallback
+}
+
+func (h *Handler) ListBranches(w http.ResponseWriter, r *http.Request) {
+	branches, err := h.db.ListBranches(r.PathValue("id"), currentUser(r).ID)
+	if err != nil {
+		if errors.Is(err, db.ErrNotFound) {
+			writeError(w, http.StatusNotFound, "Conversation not found")
+			return
+		}
+		h.logger.Error("list branches failed", "error", err)
+		writeError(w, http.StatusInternalServerError, "Failed to list branches")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"branches": branches,
+	})
+}
+
+func (h *Handler) SwitchBranch(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		MessageID string `json:"message_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.MessageID == "" {
+		writeError(w, http.StatusBadRequest, "message_id is required")
+		return
+	}
+
+	id := r.PathValue("id")
+	userID := currentUser(r).ID
+	convo, err := h.db.GetConversation(id, userID)
+	if err != nil || convo.DeletedAt != nil {
+		writeError(w, http.StatusNotFound, "Conversation not found")
+		return
+	}
+	if _, err := h.db.GetMessage(req.MessageID, id, userID); err != nil {
+		writeError(w, http.StatusNotFound, "Message not found")
+		return
+	}
+
+	leaf, err := h.db.LatestLeaf(id, req.MessageID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to switch branch")
+		return
+	}
+	if err := h.db.SetActiveMessage(id, userID, leaf); err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to switch branch")
+		return
+	}
+
+	msgs, err := h.db.GetBranch(id, userID, leaf)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to get messages")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"active_message_id": leaf,
+		"messages":          msgs,
+	})
+}
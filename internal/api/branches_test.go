@@ -0,0 +1,101 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/ifauzeee/Zee-AI/internal/db"
+)
+
+func TestEditAndSwitchBranches(t *testing.T) {
+	fake := newFakeOllama(t, "sure thing")
+	router, database := newTestRouterWithOllama(t, fake.URL)
+	headers := map[string]string{"Authorization": "Bearer s3cret"}
+
+	convo, _ := database.CreateConversation("c1", "admin-id", "Branches", "llama3")
+	for _, content := range []string{"first", "second"} {
+		rec := doRequest(t, router, http.MethodPost, "/api/chat", headers, map[string]interface{}{
+			"conversation_id": convo.ID,
+			"message":         content,
+		})
+		if rec.Code != http.StatusOK {
+			t.Fatalf("chat = %d: %s", rec.Code, rec.Body)
+		}
+	}
+	fake.waitForChats(t, false, 1)
+	original, _ := database.GetActiveBranch("c1", "admin-id")
+	if len(original) != 4 || original[2].Content != "second" {
+		t.Fatalf("original branch = %+v", original)
+	}
+
+	rec := doRequest(t, router, http.MethodPost, "/api/conversations/c1/messages/"+original[1].ID+"/edit", headers, map[string]string{"content": "nope"})
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("edit assistant message = %d, want 400", rec.Code)
+	}
+	rec = doRequest(t, router, http.MethodPost, "/api/conversations/c1/messages/"+original[2].ID+"/edit", headers, map[string]string{"content": "second, reworded"})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("edit = %d: %s", rec.Code, rec.Body)
+	}
+	if msgs := fake.lastChat(t, true).Messages; msgs[len(msgs)-1].Content != "second, reworded" {
+		t.Fatalf("edit sent %+v", msgs)
+	}
+
+	edited, _ := database.GetActiveBranch("c1", "admin-id")
+	if len(edited) != 4 || edited[1].ID != original[1].ID || edited[2].ParentID != original[1].ID ||
+		edited[2].ID == original[2].ID || edited[2].Content != "second, reworded" || edited[3].Content != "sure thing" {
+		t.Fatalf("edited branch = %+v", edited)
+	}
+	if stored, _ := database.GetMessage(original[3].ID, "c1", "admin-id"); stored == nil {
+		t.Fatal("original reply was removed by the edit")
+	}
+
+	rec = doRequest(t, router, http.MethodGet, "/api/conversations/c1/branches", headers, nil)
+	var list struct {
+		Branches []db.Branch `json:"branches"`
+	}
+	json.Unmarshal(rec.Body.Bytes(), &list)
+	if rec.Code != http.StatusOK || len(list.Branches) != 2 || list.Branches[0].IsActive || !list.Branches[1].IsActive ||
+		list.Branches[1].Preview != "second, reworded" {
+		t.Fatalf("branches = %d: %s", rec.Code, rec.Body)
+	}
+
+	rec = doRequest(t, router, http.MethodPut, "/api/conversations/c1/branch", headers, map[string]string{"message_id": original[2].ID})
+	var switched struct {
+		ActiveMessageID string       `json:"active_message_id"`
+		Messages        []db.Message `json:"messages"`
+	}
+	json.Unmarshal(rec.Body.Bytes(), &switched)
+	if rec.Code != http.StatusOK || switched.ActiveMessageID != original[3].ID || len(switched.Messages) != 4 || switched.Messages[2].Content != "second" {
+		t.Fatalf("switch = %d: %s", rec.Code, rec.Body)
+	}
+	if c, _ := database.GetConversation("c1", "admin-id"); c.ActiveMessageID != original[3].ID {
+		t.Fatalf("active message = %s, want %s", c.ActiveMessageID, original[3].ID)
+	}
+
+	for _, tc := range []struct {
+		path string
+		body interface{}
+		code int
+	}{
+		{"/api/conversations/c1/branch", map[string]string{}, http.StatusBadRequest},
+		{"/api/conversations/c1/branch", map[string]string{"message_id": "missing"}, http.StatusNotFound},
+		{"/api/conversations/missing/branch", map[string]string{"message_id": original[0].ID}, http.StatusNotFound},
+	} {
+		if rec := doRequest(t, router, http.MethodPut, tc.path, headers, tc.body); rec.Code != tc.code {
+			t.Errorf("PUT %s %v = %d, want %d", tc.path, tc.body, rec.Code, tc.code)
+		}
+	}
+
+	if err := database.TrashConversation("c1", "admin-id"); err != nil {
+		t.Fatal(err)
+	}
+	rec = doRequest(t, router, http.MethodPut, "/api/conversations/c1/branch", headers, map[string]string{"message_id": edited[2].ID})
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("switch in trashed conversation = %d, want 404", rec.Code)
+	}
+	rec = doRequest(t, router, http.MethodPost, "/api/conversations/c1/messages/"+original[2].ID+"/edit", headers, map[string]string{"content": "late"})
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("edit in trashed conversation = %d, want 404", rec.Code)
+	}
+}
@@ -0,0 +1,163 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/ifauzeee/Zee-AI/internal/ollama"
+	"github.com/ifauzeee/Zee-AI/internal/provider"
+)
+
+const (
+	StrategySlidingWindow = "sliding"
+	StrategySystemRecent  = "system_recent"
+	StrategySummarize     = "summarize"
+)
+
+const messageTokenOverhead = 4
+
+type contextFit struct {
+	messages     []ollama.ChatMessage
+	trimmed      int
+	summarized   bool
+	summaryStale bool
+}
+
+type contextLimits struct {
+	mu     sync.Mutex
+	models map[string]int
+}
+
+func (h *Handler) contextLength(ctx context.Context, model string) int {
+	h.limits.mu.Lock()
+	n, ok := h.limits.models[model]
+	h.limits.mu.Unlock()
+	if ok {
+		return n
+	}
+
+	n = h.cfg.ContextWindow
+	inspector, ok := h.llm.(provider.ModelInspector)
+	if !ok {
+		return n
+	}
+	show, err := inspector.ShowModel(ctx, model)
+	if err != nil {
+		h.logger.Warn("model info unavailable, using default context window", "model", model, "error", err)
+		return n
+	}
+	if numCtx := show.NumCtx(); numCtx > 0 {
+		n = numCtx
+	} else if max := show.ContextLength(); max > 0 && max < n {
+		n = max
+	}
+
+	h.limits.mu.Lock()
+	h.limits.models[model] = n
+	h.limits.mu.Unlock()
+	return n
+}
+
+func estimateTokens(m ollama.ChatMessage) int {
+	return len(m.Content)/4 + messageTokenOverhead
+}
+
+func (h *Handler) fitContext(ctx context.Context, model string, options *ollama.Options, messages []ollama.ChatMessage, stored storedSummary) contextFit {
+	window := 0
+	reserve := h.cfg.ContextReserve
+	if options != nil {
+		if options.NumCtx != nil {
+			window = *options.NumCtx
+		}
+		if options.NumPredict != nil && *options.NumPredict > 0 {
+			reserve = *options.NumPredict
+		}
+	}
+	if window == 0 {
+		window = h.contextLength(ctx, model)
+	}
+	budget := window - reserve
+	if budget < 0 {
+		budget = 0
+	}
+
+	fit := contextFit{messages: messages}
+	total := 0
+	for _, m := range messages {
+		total += estimateTokens(m)
+	}
+	if total <= budget || len(messages) <= 1 {
+		return fit
+	}
+
+	strategy := h.cfg.ContextStrategy
+	keepSystem := strategy != StrategySlidingWindow
+
+	used := 0
+	var pinned []ollama.ChatMessage
+	if keepSystem {
+		for _, m := range messages[:len(messages)-1] {
+			if m.Role == "system" {
+				pinned = append(pinned, m)
+				used += estimateTokens(m)
+			}
+		}
+	}
+
+	keepFrom := recentStart(messages, keepSystem, used, budget)
+	useSummary := false
+	if strategy == StrategySummarize {
+		withSummary := recentStart(messages, keepSystem, used+budget/4, budget)
+		if stored.text != "" && stored.covers >= withSummary-1 {
+			keepFrom = withSummary
+			useSummary = true
+		}
+	}
+
+	var dropped, recent []ollama.ChatMessage
+	for i, m := range messages {
+		if keepSystem && m.Role == "system" {
+			continue
+		}
+		if i < keepFrom {
+			dropped = append(dropped, m)
+		} else {
+			recent = append(recent, m)
+		}
+	}
+
+	fit.trimmed = len(dropped)
+	fit.messages = append(pinned, recent...)
+
+	if strategy == StrategySummarize && len(dropped) > 0 {
+		if !useSummary {
+			fit.summaryStale = true
+			return fit
+		}
+		note := ollama.ChatMessage{
+			Role:    "system",
+			Content: fmt.Sprintf("Summary of the earlier part of this conversation:\n%s", stored.text),
+		}
+		fit.messages = append(append(pinned, note), recent...)
+		fit.summarized = true
+	}
+	return fit
+}
+
+func recentStart(messages []ollama.ChatMessage, keepSystem bool, used, budget int) int {
+	keepFrom := len(messages) - 1
+	used += estimateTokens(messages[keepFrom])
+	for i := keepFrom - 1; i >= 0; i-- {
+		if keepSystem && messages[i].Role == "system" {
+			continue
+		}
+		cost := estimateTokens(messages[i])
+		if used+cost > budget {
+			break
+		}
+		used += cost
+		keepFrom = i
+	}
+	return keepFrom
+}
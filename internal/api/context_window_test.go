@@ -0,0 +1,110 @@
+package api
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/ifauzeee/Zee-AI/internal/config"
+	"github.com/ifauzeee/Zee-AI/internal/ollama"
+)
+
+func newContextTestHandler(strategy string, window int) *Handler {
+	cfg := &config.Config{ContextStrategy: strategy, ContextReserve: 10}
+	h := NewHandler(nil, ollama.New("http://127.0.0.1:0"), cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	h.limits.models["test"] = window
+	return h
+}
+
+func testHistory() []ollama.ChatMessage {
+	long := strings.Repeat("x", 160)
+	return []ollama.ChatMessage{
+		{Role: "system", Content: "be nice"},
+		{Role: "user", Content: long},
+		{Role: "assistant", Content: long},
+		{Role: "user", Content: long},
+		{Role: "assistant", Content: long},
+		{Role: "user", Content: "last question"},
+	}
+}
+
+func TestFitContextUnderBudget(t *testing.T) {
+	h := newContextTestHandler(StrategySystemRecent, 10000)
+	fit := h.fitContext(context.Background(), "test", nil, testHistory(), storedSummary{covers: -1})
+	if fit.trimmed != 0 || len(fit.messages) != 6 {
+		t.Fatalf("trimmed = %d, messages = %d; want 0 and 6", fit.trimmed, len(fit.messages))
+	}
+}
+
+func TestFitContextSystemRecent(t *testing.T) {
+	h := newContextTestHandler(StrategySystemRecent, 100)
+	fit := h.fitContext(context.Background(), "test", nil, testHistory(), storedSummary{covers: -1})
+
+	if fit.trimmed != 3 {
+		t.Fatalf("trimmed = %d, want 3", fit.trimmed)
+	}
+	if fit.messages[0].Role != "system" {
+		t.Fatalf("first message role = %q, want system", fit.messages[0].Role)
+	}
+	if last := fit.messages[len(fit.messages)-1]; last.Content != "last question" {
+		t.Fatalf("last message = %q, want the latest user message", last.Content)
+	}
+}
+
+func TestFitContextSlidingWindowDropsSystem(t *testing.T) {
+	h := newContextTestHandler(StrategySlidingWindow, 100)
+	fit := h.fitContext(context.Background(), "test", nil, testHistory(), storedSummary{covers: -1})
+
+	for _, m := range fit.messages {
+		if m.Role == "system" {
+			t.Fatalf("sliding window kept the system prompt: %+v", fit.messages)
+		}
+	}
+	if last := fit.messages[len(fit.messages)-1]; last.Content != "last question" {
+		t.Fatalf("last message = %q, want the latest user message", last.Content)
+	}
+}
+
+func TestFitContextAlwaysKeepsLatestMessage(t *testing.T) {
+	h := newContextTestHandler(StrategySystemRecent, 1)
+	fit := h.fitContext(context.Background(), "test", nil, testHistory(), storedSummary{covers: -1})
+
+	if len(fit.messages) != 2 || fit.messages[1].Content != "last question" {
+		t.Fatalf("messages = %+v, want system prompt and latest message", fit.messages)
+	}
+}
+
+type noChatLLM struct {
+	*blockingLLM
+	t *testing.T
+}
+
+func (l noChatLLM) Chat(ctx context.Context, req *ollama.ChatRequest) (*ollama.ChatResponse, error) {
+	l.t.Fatalf("fitContext called the model: %+v", req)
+	return nil, nil
+}
+
+func TestFitContextSummarize(t *testing.T) {
+	h := newContextTestHandler(StrategySummarize, 100)
+	h.llm = noChatLLM{newBlockingLLM(false), t}
+
+	fit := h.fitContext(context.Background(), "test", nil, testHistory(), storedSummary{covers: -1})
+	if !fit.summaryStale || fit.summarized || fit.trimmed != 3 || len(fit.messages) != 3 {
+		t.Fatalf("without a summary: %+v, want system_recent trimming and a stale summary", fit)
+	}
+
+	fit = h.fitContext(context.Background(), "test", nil, testHistory(), storedSummary{text: "they talked about x", covers: 1})
+	if !fit.summaryStale || fit.summarized {
+		t.Fatalf("with a summary that misses trimmed turns: %+v, want it ignored", fit)
+	}
+
+	fit = h.fitContext(context.Background(), "test", nil, testHistory(), storedSummary{text: "they talked about x", covers: 4})
+	if fit.summaryStale || !fit.summarized || len(fit.messages) < 2 || !strings.Contains(fit.messages[1].Content, "they talked about x") {
+		t.Fatalf("with a covering summary: %+v", fit)
+	}
+	if last := fit.messages[len(fit.messages)-1]; last.Content != "last question" {
+		t.Fatalf("last message = %q, want the latest user message", last.Content)
+	}
+}
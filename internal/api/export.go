@@ -0,0 +1,305 @@
+package api
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ifauzeee/Zee-AI/internal/db"
+)
+
+const (
+	exportFormatName    = "zee-ai"
+	exportFormatVersion = 1
+	exportTimeLayout    = "2006-01-02 15:04:05 MST"
+)
+
+type conversationExport struct {
+	Format       string          `json:"format"`
+	Version      int             `json:"version"`
+	ExportedAt   time.Time       `json:"exported_at"`
+	Conversation db.Conversation `json:"conversation"`
+	Messages     []db.Message    `json:"messages"`
+}
+
+type exporter struct {
+	ext         string
+	contentType string
+	render      func(w io.Writer, convo *db.Conversation, branch, all []db.Message) error
+}
+
+var exporters = map[string]exporter{
+	"md":   {ext: "md", contentType: "text/markdown; charset=utf-8", render: renderMarkdown},
+	"json": {ext: "json", contentType: "application/json", render: renderJSON},
+	"html": {ext: "html", contentType: "text/html; charset=utf-8", render: renderHTML},
+}
+
+func exporterFor(w http.ResponseWriter, r *http.Request) (exporter, bool) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "md"
+	}
+	if format == "markdown" {
+		format = "md"
+	}
+	e, ok := exporters[format]
+	if !ok {
+		writeError(w, http.StatusBadRequest, "format must be one of md, json, html")
+	}
+	return e, ok
+}
+
+func (h *Handler) ExportConversation(w http.ResponseWriter, r *http.Request) {
+	e, ok := exporterFor(w, r)
+	if !ok {
+		return
+	}
+
+	id := r.PathValue("id")
+	userID := currentUser(r).ID
+	convo, err := h.db.GetConversation(id, userID)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "Conversation not found")
+		return
+	}
+	branch, all, err := h.exportMessages(convo, userID)
+	if err != nil {
+		h.logger.Error("export conversation failed", "error", err)
+		writeError(w, http.StatusInternalServerError, "Failed to export conversation")
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := e.render(&buf, convo, branch, all); err != nil {
+		h.logger.Error("render export failed", "error", err)
+		writeError(w, http.StatusInternalServerError, "Failed to export conversation")
+		return
+	}
+
+	w.Header().Set("Content-Type", e.contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", exportFilename(convo, e.ext)))
+	w.WriteHeader(http.StatusOK)
+	w.Write(buf.Bytes())
+}
+
+func (h *Handler) ExportAll(w http.ResponseWriter, r *http.Request) {
+	e, ok := exporterFor(w, r)
+	if !ok {
+		return
+	}
+
+	userID := currentUser(r).ID
+	convos, err := h.db.ListConversations(userID)
+	if err != nil {
+		h.logger.Error("list conversations failed", "error", err)
+		writeError(w, http.StatusInternalServerError, "Failed to export conversations")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", "zee-ai-export-"+time.Now().Format("2006-01-02")+".zip"))
+	w.WriteHeader(http.StatusOK)
+
+	zw := zip.NewWriter(w)
+	for i := range convos {
+		convo := &convos[i]
+		branch, all, err := h.exportMessages(convo, userID)
+		if err != nil {
+			h.logger.Error("export conversation failed", "id", convo.ID, "error", err)
+			continue
+		}
+		f, err := zw.CreateHeader(&zip.FileHeader{
+			Name:     exportFilename(convo, e.ext),
+			Method:   zip.Deflate,
+			Modified: convo.UpdatedAt,
+		})
+		if err != nil {
+			h.logger.Error("export zip failed", "error", err)
+			return
+		}
+		if err := e.render(f, convo, branch, all); err != nil {
+			h.logger.Error("render export failed", "id", convo.ID, "error", err)
+			return
+		}
+	}
+	if err := zw.Close(); err != nil {
+		h.logger.Error("export zip failed", "error", err)
+	}
+}
+
+func (h *Handler) exportMessages(convo *db.Conversation, userID string) (branch, all []db.Message, err error) {
+	all, err = h.db.GetMessages(convo.ID, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if convo.ActiveMessageID != "" {
+		if branch, err = h.db.GetBranch(convo.ID, userID, convo.ActiveMessageID); err != nil {
+			return nil, nil, err
+		}
+	}
+	if all == nil {
+		all = []db.Message{}
+	}
+	return branch, all, nil
+}
+
+func exportFilename(convo *db.Conversation, ext string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(convo.Title) {
+		if r >= 'a' && r <= 'z' || r >= '0' && r <= '9' {
+			b.WriteRune(r)
+			dash = false
+		} else if !dash && b.Len() > 0 {
+			b.WriteByte('-')
+			dash = true
+		}
+		if b.Len() >= 50 {
+			break
+		}
+	}
+	slug := strings.Trim(b.String(), "-")
+	if slug == "" {
+		slug = "conversation"
+	}
+	id := convo.ID
+	if len(id) > 8 {
+		id = id[:8]
+	}
+	return slug + "-" + id + "." + ext
+}
+
+func roleLabel(role string) string {
+	if role == "" {
+		return ""
+	}
+	return strings.ToUpper(role[:1]) + role[1:]
+}
+
+func messageDetails(m db.Message) string {
+	parts := []string{m.CreatedAt.Format(exportTimeLayout)}
+	if m.Model != "" {
+		parts = append(parts, m.Model)
+	}
+	if m.TokensUsed > 0 {
+		parts = append(parts, fmt.Sprintf("%d tokens", m.TokensUsed))
+	}
+	if m.Duration > 0 {
+		parts = append(parts, fmt.Sprintf("%.1fs", m.Duration))
+	}
+	if m.Interrupted {
+		parts = append(parts, "interrupted")
+	}
+	if m.ToolName != "" {
+		parts = append(parts, m.ToolName)
+	}
+	if names := toolCallNames(m); len(names) > 0 {
+		parts = append(parts, "calls "+strings.Join(names, ", "))
+	}
+	return strings.Join(parts, " · ")
+}
+
+func tagNames(convo *db.Conversation) string {
+	names := make([]string, len(convo.Tags))
+	for i, t := range convo.Tags {
+		names[i] = t.Name
+	}
+	return strings.Join(names, ", ")
+}
+
+func totalTokens(msgs []db.Message) int {
+	total := 0
+	for _, m := range msgs {
+		total += m.TokensUsed
+	}
+	return total
+}
+
+func renderJSON(w io.Writer, convo *db.Conversation, _, all []db.Message) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(conversationExport{
+		Format:       exportFormatName,
+		Version:      exportFormatVersion,
+		ExportedAt:   time.Now(),
+		Conversation: *convo,
+		Messages:     all,
+	})
+}
+
+func renderMarkdown(w io.Writer, convo *db.Conversation, branch, _ []db.Message) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n", convo.Title)
+	if convo.Model != "" {
+		fmt.Fprintf(&b, "- **Model:** %s\n", convo.Model)
+	}
+	fmt.Fprintf(&b, "- **Created:** %s\n", convo.CreatedAt.Format(exportTimeLayout))
+	fmt.Fprintf(&b, "- **Updated:** %s\n", convo.UpdatedAt.Format(exportTimeLayout))
+	fmt.Fprintf(&b, "- **Messages:** %d\n", len(branch))
+	fmt.Fprintf(&b, "- **Tokens:** %d\n", totalTokens(branch))
+	if tags := tagNames(convo); tags != "" {
+		fmt.Fprintf(&b, "- **Tags:** %s\n", tags)
+	}
+	for _, m := range branch {
+		fmt.Fprintf(&b, "\n---\n\n### %s\n\n_%s_\n\n%s\n", roleLabel(m.Role), messageDetails(m), m.Content)
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+var htmlExportTemplate = template.Must(template.New("export").Funcs(template.FuncMap{
+	"role":    roleLabel,
+	"details": messageDetails,
+	"tags":    tagNames,
+	"tokens":  totalTokens,
+	"time":    func(t time.Time) string { return t.Format(exportTimeLayout) },
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Conversation.Title}}</title>
+<style>
+body { font-family: system-ui, sans-serif; max-width: 860px; margin: 2rem auto; padding: 0 1rem; color: #1f2937; }
+header { border-bottom: 1px solid #e5e7eb; margin-bottom: 1.5rem; }
+dl { display: grid; grid-template-columns: max-content 1fr; gap: .25rem 1rem; font-size: .9rem; }
+dt { font-weight: 600; }
+.message { border: 1px solid #e5e7eb; border-radius: 8px; padding: 1rem; margin-bottom: 1rem; }
+.message.user { background: #f5f3ff; }
+.message.system { background: #f9fafb; }
+.role { font-weight: 600; }
+.meta { color: #6b7280; font-size: .8rem; }
+.content { white-space: pre-wrap; margin-top: .5rem; }
+</style>
+</head>
+<body>
+<header>
+<h1>{{.Conversation.Title}}</h1>
+<dl>
+{{with .Conversation.Model}}<dt>Model</dt><dd>{{.}}</dd>{{end}}
+<dt>Created</dt><dd>{{time .Conversation.CreatedAt}}</dd>
+<dt>Updated</dt><dd>{{time .Conversation.UpdatedAt}}</dd>
+<dt>Messages</dt><dd>{{len .Messages}}</dd>
+<dt>Tokens</dt><dd>{{tokens .Messages}}</dd>
+{{with tags .Conversation}}<dt>Tags</dt><dd>{{.}}</dd>{{end}}
+</dl>
+</header>
+{{range .Messages}}<section class="message {{.Role}}">
+<div><span class="role">{{role .Role}}</span> <span class="meta">{{details .}}</span></div>
+<div class="content">{{.Content}}</div>
+</section>
+{{end}}</body>
+</html>
+`))
+
+func renderHTML(w io.Writer, convo *db.Conversation, branch, _ []db.Message) error {
+	return htmlExportTemplate.Execute(w, struct {
+		Conversation *db.Conversation
+		Messages     []db.Message
+	}{convo, branch})
+}
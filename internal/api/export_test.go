@@ -0,0 +1,73 @@
+package api
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/ifauzeee/Zee-AI/internal/db"
+)
+
+func TestExportConversation(t *testing.T) {
+	router, database := newTestRouter(t)
+	headers := map[string]string{"X-API-Key": "s3cret"}
+
+	if _, err := database.CreateConversation("conv-1", "admin-id", "Deploy <plan>", "llama3"); err != nil {
+		t.Fatalf("create conversation: %v", err)
+	}
+	for _, m := range []*db.Message{
+		{ID: "m1", ConversationID: "conv-1", Role: "user", Content: "How do we <deploy>?"},
+		{ID: "m2", ConversationID: "conv-1", ParentID: "m1", Role: "assistant", Content: "Carefully.", Model: "llama3", TokensUsed: 42},
+	} {
+		if err := database.CreateMessage(m); err != nil {
+			t.Fatalf("create message: %v", err)
+		}
+	}
+
+	rec := doRequest(t, router, http.MethodGet, "/api/conversations/conv-1/export?format=md", headers, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("md export = %d: %s", rec.Code, rec.Body)
+	}
+	md := rec.Body.String()
+	for _, want := range []string{"# Deploy <plan>", "### User", "### Assistant", "llama3 · 42 tokens", "Carefully."} {
+		if !strings.Contains(md, want) {
+			t.Errorf("markdown missing %q:\n%s", want, md)
+		}
+	}
+	if cd := rec.Header().Get("Content-Disposition"); !strings.Contains(cd, "deploy-plan-conv-1.md") {
+		t.Errorf("Content-Disposition = %q", cd)
+	}
+
+	rec = doRequest(t, router, http.MethodGet, "/api/conversations/conv-1/export?format=html", headers, nil)
+	if html := rec.Body.String(); !strings.Contains(html, "How do we &lt;deploy&gt;?") {
+		t.Errorf("html export does not escape content:\n%s", html)
+	}
+
+	rec = doRequest(t, router, http.MethodGet, "/api/conversations/conv-1/export?format=json", headers, nil)
+	var export conversationExport
+	if err := json.Unmarshal(rec.Body.Bytes(), &export); err != nil {
+		t.Fatalf("decode json export: %v", err)
+	}
+	if export.Format != exportFormatName || len(export.Messages) != 2 || export.Messages[1].TokensUsed != 42 {
+		t.Errorf("json export = %+v", export)
+	}
+
+	if rec := doRequest(t, router, http.MethodGet, "/api/conversations/conv-1/export?format=pdf", headers, nil); rec.Code != http.StatusBadRequest {
+		t.Errorf("unknown format = %d, want 400", rec.Code)
+	}
+
+	rec = doRequest(t, router, http.MethodGet, "/api/export?format=json", headers, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("export all = %d: %s", rec.Code, rec.Body)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(rec.Body.Bytes()), int64(rec.Body.Len()))
+	if err != nil {
+		t.Fatalf("open zip: %v", err)
+	}
+	if len(zr.File) != 1 || zr.File[0].Name != "deploy-plan-conv-1.json" {
+		t.Fatalf("zip entries = %v", zr.File)
+	}
+}
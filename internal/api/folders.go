@@ -0,0 +1,124 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/ifauzeee/Zee-AI/internal/db"
+)
+
+func (h *Handler) ListFolders(w http.ResponseWriter, r *http.Request) {
+	folders, err := h.db.ListFolders(currentUser(r).ID)
+	if err != nil {
+		h.logger.Error("list folders failed", "error", err)
+		writeError(w, http.StatusInternalServerError, "Failed to list folders")
+		return
+	}
+	if folders == nil {
+		folders = []db.Folder{}
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"folders": folders,
+	})
+}
+
+func (h *Handler) CreateFolder(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Name     string `json:"name"`
+		ParentID string `json:"parent_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		writeError(w, http.StatusBadRequest, "Name is required")
+		return
+	}
+
+	folder := &db.Folder{
+		ID:       uuid.New().String(),
+		UserID:   currentUser(r).ID,
+		ParentID: req.ParentID,
+		Name:     req.Name,
+	}
+	if err := h.db.CreateFolder(folder); err != nil {
+		if errors.Is(err, db.ErrFolderNotFound) {
+			writeError(w, http.StatusBadRequest, "Parent folder not found")
+			return
+		}
+		h.logger.Error("create folder failed", "error", err)
+		writeError(w, http.StatusInternalServerError, "Failed to create folder")
+		return
+	}
+	writeJSON(w, http.StatusCreated, folder)
+}
+
+func (h *Handler) UpdateFolder(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	userID := currentUser(r).ID
+	folder, err := h.db.GetFolder(id, userID)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "Folder not found")
+		return
+	}
+
+	var req struct {
+		Name     *string `json:"name"`
+		ParentID *string `json:"parent_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if req.Name != nil {
+		folder.Name = strings.TrimSpace(*req.Name)
+		if folder.Name == "" {
+			writeError(w, http.StatusBadRequest, "Name is required")
+			return
+		}
+	}
+	if req.ParentID != nil {
+		folder.ParentID = *req.ParentID
+	}
+
+	err = h.db.UpdateFolder(id, userID, folder.Name, folder.ParentID)
+	switch {
+	case errors.Is(err, db.ErrNotFound):
+		writeError(w, http.StatusNotFound, "Folder not found")
+		return
+	case errors.Is(err, db.ErrFolderNotFound):
+		writeError(w, http.StatusBadRequest, "Parent folder not found")
+		return
+	case errors.Is(err, db.ErrFolderCycle):
+		writeError(w, http.StatusBadRequest, "A folder cannot be moved into itself or one of its subfolders")
+		return
+	case err != nil:
+		h.logger.Error("update folder failed", "error", err)
+		writeError(w, http.StatusInternalServerError, "Failed to update folder")
+		return
+	}
+
+	folder, err = h.db.GetFolder(id, userID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to update folder")
+		return
+	}
+	writeJSON(w, http.StatusOK, folder)
+}
+
+func (h *Handler) DeleteFolder(w http.ResponseWriter, r *http.Request) {
+	if err := h.db.DeleteFolder(r.PathValue("id"), currentUser(r).ID); err != nil {
+		if errors.Is(err, db.ErrNotFound) {
+			writeError(w, http.StatusNotFound, "Folder not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "Failed to delete folder")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"status": "deleted"})
+}
@@ -0,0 +1,100 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/ifauzeee/Zee-AI/internal/db"
+)
+
+func TestTagsAndFolders(t *testing.T) {
+	router, database := newTestRouter(t)
+	headers := map[string]string{"X-API-Key": "s3cret"}
+
+	create := func(path string, body interface{}, out interface{}) {
+		t.Helper()
+		rec := doRequest(t, router, http.MethodPost, path, headers, body)
+		if rec.Code != http.StatusCreated {
+			t.Fatalf("POST %s = %d: %s", path, rec.Code, rec.Body)
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), out); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+	}
+	listIDs := func(query string) []string {
+		t.Helper()
+		rec := doRequest(t, router, http.MethodGet, "/api/conversations?"+query, headers, nil)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("list = %d: %s", rec.Code, rec.Body)
+		}
+		var resp struct {
+			Conversations []db.Conversation `json:"conversations"`
+		}
+		json.Unmarshal(rec.Body.Bytes(), &resp)
+		var ids []string
+		for _, c := range resp.Conversations {
+			ids = append(ids, c.ID)
+		}
+		return ids
+	}
+
+	var work, project db.Folder
+	create("/api/folders", map[string]string{"name": "Work"}, &work)
+	create("/api/folders", map[string]string{"name": "Project", "parent_id": work.ID}, &project)
+
+	var urgent db.Tag
+	create("/api/tags", map[string]string{"name": "urgent", "color": "#f00"}, &urgent)
+	if rec := doRequest(t, router, http.MethodPost, "/api/tags", headers, map[string]string{"name": "URGENT"}); rec.Code != http.StatusConflict {
+		t.Fatalf("duplicate tag = %d, want 409", rec.Code)
+	}
+
+	for _, id := range []string{"a", "b"} {
+		if _, err := database.CreateConversation(id, "admin-id", "Chat", "llama"); err != nil {
+			t.Fatalf("create conversation: %v", err)
+		}
+	}
+	rec := doRequest(t, router, http.MethodPatch, "/api/conversations/a", headers, map[string]interface{}{
+		"folder_id": project.ID,
+		"tags":      []string{urgent.ID},
+	})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("assign = %d: %s", rec.Code, rec.Body)
+	}
+	if rec := doRequest(t, router, http.MethodPatch, "/api/conversations/b", headers, map[string]interface{}{"tags": []string{"missing"}}); rec.Code != http.StatusBadRequest {
+		t.Fatalf("unknown tag = %d, want 400", rec.Code)
+	}
+
+	if ids := listIDs("tag=" + urgent.ID); len(ids) != 1 || ids[0] != "a" {
+		t.Fatalf("tag filter = %v", ids)
+	}
+	if ids := listIDs("folder=" + work.ID); len(ids) != 1 || ids[0] != "a" {
+		t.Fatalf("folder filter should include subfolders, got %v", ids)
+	}
+
+	convo, err := database.GetConversation("a", "admin-id")
+	if err != nil {
+		t.Fatalf("get conversation: %v", err)
+	}
+	if convo.FolderID != project.ID || len(convo.Tags) != 1 || convo.Tags[0].Name != "urgent" {
+		t.Fatalf("conversation = %+v", convo)
+	}
+
+	if rec := doRequest(t, router, http.MethodPatch, "/api/folders/"+work.ID, headers, map[string]string{"parent_id": project.ID}); rec.Code != http.StatusBadRequest {
+		t.Fatalf("folder cycle = %d, want 400", rec.Code)
+	}
+
+	if rec := doRequest(t, router, http.MethodDelete, "/api/folders/"+project.ID, headers, nil); rec.Code != http.StatusOK {
+		t.Fatalf("delete folder = %d", rec.Code)
+	}
+	if convo, _ := database.GetConversation("a", "admin-id"); convo.FolderID != work.ID {
+		t.Fatalf("conversation folder after delete = %q, want parent %q", convo.FolderID, work.ID)
+	}
+
+	if rec := doRequest(t, router, http.MethodDelete, "/api/tags/"+urgent.ID, headers, nil); rec.Code != http.StatusOK {
+		t.Fatalf("delete tag = %d", rec.Code)
+	}
+	if ids := listIDs("tag=" + urgent.ID); len(ids) != 0 {
+		t.Fatalf("deleted tag still filters %v", ids)
+	}
+}
@@ -0,0 +1,60 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/ifauzeee/Zee-AI/internal/jsonschema"
+)
+
+const maxFormatRetries = 3
+
+type outputFormat struct {
+	raw     json.RawMessage
+	schema  *jsonschema.Schema
+	retries int
+}
+
+func (h *Handler) outputFormat(w http.ResponseWriter, raw json.RawMessage, retries int) (*outputFormat, bool) {
+	if retries < 0 || retries > maxFormatRetries {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("format_retries must be between 0 and %d", maxFormatRetries))
+		return nil, false
+	}
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 || bytes.Equal(raw, []byte("null")) {
+		return nil, true
+	}
+
+	switch raw[0] {
+	case '"':
+		var name string
+		if err := json.Unmarshal(raw, &name); err != nil || name != "json" {
+			writeError(w, http.StatusBadRequest, `format must be "json" or a JSON schema object`)
+			return nil, false
+		}
+		return &outputFormat{raw: raw, schema: &jsonschema.Schema{}, retries: retries}, true
+	case '{':
+		schema, err := jsonschema.Compile(raw)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "Invalid format schema: "+err.Error())
+			return nil, false
+		}
+		return &outputFormat{raw: raw, schema: schema, retries: retries}, true
+	}
+	writeError(w, http.StatusBadRequest, `format must be "json" or a JSON schema object`)
+	return nil, false
+}
+
+func (f *outputFormat) check(output string) error {
+	return f.schema.ValidateJSON([]byte(output))
+}
+
+func (f *outputFormat) retryPrompt(err error) string {
+	prompt := "Your previous reply was rejected: " + err.Error() + ". Reply again with only a JSON value"
+	if f.raw[0] == '{' {
+		prompt += " that matches this JSON schema:\n" + string(f.raw)
+	}
+	return prompt + "."
+}
@@ -0,0 +1,104 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ifauzeee/Zee-AI/internal/ollama"
+)
+
+func TestChatStructuredOutput(t *testing.T) {
+	replies := []string{`{"name": "Ada"}`, `{"name": "Ada", "age": 36}`}
+	var requests []ollama.ChatRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/chat" {
+			http.NotFound(w, r)
+			return
+		}
+		var req ollama.ChatRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		if !req.Stream {
+			fmt.Fprint(w, `{"message":{"role":"assistant","content":"Ada"},"done":true}`)
+			return
+		}
+		reply := replies[len(requests)%len(replies)]
+		requests = append(requests, req)
+		fmt.Fprintf(w, `{"message":{"role":"assistant","content":%q},"done":false}`+"\n", reply)
+		fmt.Fprint(w, `{"message":{"role":"assistant","content":""},"done":true,"eval_count":7}`+"\n")
+	}))
+	t.Cleanup(srv.Close)
+	router, database := newTestRouterWithOllama(t, srv.URL)
+	headers := map[string]string{"Authorization": "Bearer s3cret"}
+
+	schema := map[string]interface{}{
+		"type":     "object",
+		"required": []string{"name", "age"},
+		"properties": map[string]interface{}{
+			"name": map[string]interface{}{"type": "string"},
+			"age":  map[string]interface{}{"type": "integer", "minimum": 0},
+		},
+	}
+	for _, body := range []map[string]interface{}{
+		{"format": "yaml"},
+		{"format": map[string]interface{}{"type": "nope"}},
+		{"format": map[string]interface{}{"$ref": "#/$defs/person"}},
+		{"format": "json", "format_retries": 10},
+	} {
+		body["model"], body["message"] = "llama3", "who?"
+		if rec := doRequest(t, router, http.MethodPost, "/api/chat", headers, body); rec.Code != http.StatusBadRequest {
+			t.Fatalf("format %v = %d, want 400", body["format"], rec.Code)
+		}
+	}
+
+	rec := doRequest(t, router, http.MethodPost, "/api/chat", headers, map[string]interface{}{
+		"model":          "llama3",
+		"message":        "who wrote the first program?",
+		"format":         schema,
+		"format_retries": 1,
+	})
+	var events []map[string]interface{}
+	for _, line := range strings.Split(rec.Body.String(), "\n") {
+		if data, ok := strings.CutPrefix(line, "data: "); ok {
+			var event map[string]interface{}
+			json.Unmarshal([]byte(data), &event)
+			events = append(events, event)
+		}
+	}
+	if len(requests) != 2 || string(requests[0].Format) == "" {
+		t.Fatalf("ollama requests = %+v", requests)
+	}
+	if retry := requests[1].Messages; len(retry) != 3 || !strings.Contains(retry[2].Content, "missing required property") {
+		t.Fatalf("retry messages = %+v", retry)
+	}
+
+	var retried bool
+	var final map[string]interface{}
+	for _, event := range events {
+		retried = retried || event["type"] == "format_retry"
+		if event["done"] == true {
+			final = event
+		}
+	}
+	status, _ := final["format"].(map[string]interface{})
+	if !retried || status["valid"] != true || status["attempts"] != float64(2) {
+		t.Fatalf("events = %v", events)
+	}
+
+	convID := events[0]["conversation_id"].(string)
+	msgs, _ := database.GetMessages(convID, "admin-id")
+	if len(msgs) != 2 || msgs[1].Content != replies[1] {
+		t.Fatalf("stored messages = %+v", msgs)
+	}
+
+	requests = nil
+	rec = doRequest(t, router, http.MethodPost, "/api/conversations/"+convID+"/regenerate", headers, map[string]interface{}{
+		"format": schema,
+	})
+	if !strings.Contains(rec.Body.String(), `"valid":false`) || len(requests) != 1 {
+		t.Fatalf("regenerate without retries = %s", rec.Body)
+	}
+}
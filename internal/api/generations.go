@@ -0,0 +1,64 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+)
+
+var errGenerationStopped = errors.New("generation stopped")
+
+type generationRegistry struct {
+	mu     sync.Mutex
+	active map[string]context.CancelCauseFunc
+}
+
+func newGenerationRegistry() *generationRegistry {
+	return &generationRegistry{active: make(map[string]context.CancelCauseFunc)}
+}
+
+func (g *generationRegistry) start(parent context.Context, conversationID string) (context.Context, func(), bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if _, busy := g.active[conversationID]; busy {
+		return nil, nil, false
+	}
+
+	ctx, cancel := context.WithCancelCause(parent)
+	g.active[conversationID] = cancel
+
+	finish := func() {
+		g.mu.Lock()
+		delete(g.active, conversationID)
+		g.mu.Unlock()
+		cancel(nil)
+	}
+	return ctx, finish, true
+}
+
+func (g *generationRegistry) stop(conversationID string) bool {
+	g.mu.Lock()
+	cancel, ok := g.active[conversationID]
+	g.mu.Unlock()
+
+	if ok {
+		cancel(errGenerationStopped)
+	}
+	return ok
+}
+
+func (h *Handler) StopGeneration(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if _, err := h.db.GetConversation(id, currentUser(r).ID); err != nil {
+		writeError(w, http.StatusNotFound, "Conversation not found")
+		return
+	}
+
+	if !h.generations.stop(id) {
+		writeError(w, http.StatusNotFound, "No active generation for this conversation")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"status": "stopping"})
+}
@@ -0,0 +1,64 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestStopGeneration(t *testing.T) {
+	llm := newBlockingLLM(true)
+	router, database := newTestRouterWithProvider(t, llm)
+	headers := map[string]string{"Authorization": "Bearer s3cret"}
+	database.CreateConversation("c1", "admin-id", "Stop", "llama3")
+
+	rec := doRequest(t, router, http.MethodPost, "/api/conversations/c1/stop", headers, nil)
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("stop while idle = %d, want 404", rec.Code)
+	}
+
+	stream, _, done := startStream(t, router, "/api/chat", map[string]string{
+		"conversation_id": "c1",
+		"message":         "count to a million",
+	})
+	llm.waitStarted(t)
+
+	rec = doRequest(t, router, http.MethodPost, "/api/conversations/c1/regenerate", headers, nil)
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("regenerate during generation = %d, want 409", rec.Code)
+	}
+	rec = doRequest(t, router, http.MethodPost, "/api/conversations/c1/stop", map[string]string{"X-API-Key": "wrong"}, nil)
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("stop without credentials = %d, want 401", rec.Code)
+	}
+	rec = doRequest(t, router, http.MethodPost, "/api/conversations/c1/stop", headers, nil)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"stopping"`) {
+		t.Fatalf("stop = %d: %s", rec.Code, rec.Body)
+	}
+	waitDone(t, done)
+
+	replies := assistantMessages(t, database, "c1")
+	if len(replies) != 1 || replies[0].Content != "partial" || !replies[0].Interrupted {
+		t.Fatalf("stored replies = %+v, want one interrupted partial reply", replies)
+	}
+
+	var stopped map[string]string
+	for _, line := range strings.Split(stream.Body.String(), "\n") {
+		data, ok := strings.CutPrefix(line, "data: ")
+		if ok && strings.Contains(data, `"type":"stopped"`) {
+			json.Unmarshal([]byte(data), &stopped)
+		}
+	}
+	if stopped["conversation_id"] != "c1" || stopped["message_id"] != replies[0].ID {
+		t.Fatalf("stopped event = %v in stream %s", stopped, stream.Body)
+	}
+	if strings.Contains(stream.Body.String(), `"type":"error"`) {
+		t.Fatalf("stop reported as an error: %s", stream.Body)
+	}
+
+	rec = doRequest(t, router, http.MethodPost, "/api/conversations/c1/stop", headers, nil)
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("second stop = %d, want 404", rec.Code)
+	}
+}
@@ -1,33 +1,53 @@
 package api
 
 import (
+	"context"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/ifauzeee/Zee-AI/internal/db"
 	"github.com/ifauzeee/Zee-AI/internal/ollama"
+	"github.com/ifauzeee/Zee-AI/internal/provider"
+	"github.com/ifauzeee/Zee-AI/internal/tools"
 )
 
 func (h *Handler) HealthCheck(w http.ResponseWriter, r *http.Request) {
-	ollamaOK := h.ollama.IsHealthy()
+	ollamaOK := h.llm.IsHealthy(r.Context())
 	status := "healthy"
 	if !ollamaOK {
 		status = "degraded"
 	}
 
-	writeJSON(w, http.StatusOK, map[string]interface{}{
+	resp := map[string]interface{}{
 		"status":  status,
 		"ollama":  ollamaOK,
 		"version": "1.0.0",
-	})
+	}
+	if reporter, ok := h.llm.(provider.HealthReporter); ok {
+		resp["providers"] = reporter.Health(r.Context())
+	}
+	if reporter, ok := h.llm.(provider.BackendReporter); ok {
+		if backends := reporter.Backends(); len(backends) > 0 {
+			for _, b := range backends {
+				if !b.Healthy {
+					resp["status"] = "degraded"
+				}
+			}
+			resp["backends"] = backends
+		}
+	}
+	writeJSON(w, http.StatusOK, resp)
 }
 
 func (h *Handler) ListModels(w http.ResponseWriter, r *http.Request) {
-	models, err := h.ollama.ListModels()
+	models, err := h.llm.ListModels(r.Context())
 	if err != nil {
 		h.logger.Error("list models failed", "error", err)
 		writeError(w, http.StatusServiceUnavailable, "Cannot connect to Ollama. Make sure Ollama is running.")
@@ -47,6 +67,12 @@ func (h *Handler) PullModel(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	manager, ok := h.llm.(provider.ModelManager)
+	if !ok {
+		writeError(w, http.StatusNotImplemented, "Model management is not supported by this backend")
+		return
+	}
+
 	w.Header().Set("Content-Type", "text/event-stream")
 	w.Header().Set("Cache-Control", "no-cache")
 	w.Header().Set("Connection", "keep-alive")
@@ -59,13 +85,17 @@ func (h *Handler) PullModel(w http.ResponseWriter, r *http.Request) {
 
 	h.logger.Info("pulling model", "name", req.Name)
 
-	err := h.ollama.PullModel(req.Name, func(resp ollama.PullResponse) error {
+	err := manager.PullModel(r.Context(), req.Name, func(resp ollama.PullResponse) error {
 		data, _ := json.Marshal(resp)
 		fmt.Fprintf(w, "data: %s\n\n", data)
 		flusher.Flush()
 		return nil
 	})
 
+	if r.Context().Err() != nil {
+		h.logger.Info("model pull aborted by client", "name", req.Name)
+		return
+	}
 	if err != nil {
 		h.logger.Error("pull model failed", "name", req.Name, "error", err)
 		fmt.Fprintf(w, "data: {\"error\": \"%s\"}\n\n", err.Error())
@@ -84,7 +114,16 @@ func (h *Handler) DeleteModel(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.ollama.DeleteModel(name); err != nil {
+	manager, ok := h.llm.(provider.ModelManager)
+	if !ok {
+		writeError(w, http.StatusNotImplemented, "Model management is not supported by this backend")
+		return
+	}
+	if err := manager.DeleteModel(r.Context(), name); err != nil {
+		if errors.Is(err, provider.ErrUnsupported) {
+			writeError(w, http.StatusBadRequest, "Models on this backend cannot be deleted")
+			return
+		}
 		h.logger.Error("delete model failed", "name", name, "error", err)
 		writeError(w, http.StatusInternalServerError, err.Error())
 		return
@@ -94,7 +133,33 @@ func (h *Handler) DeleteModel(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *Handler) ListConversations(w http.ResponseWriter, r *http.Request) {
-	convos, err := h.db.ListConversations()
+	q := r.URL.Query()
+	opts := db.ConversationListOptions{
+		Cursor:   q.Get("cursor"),
+		Model:    q.Get("model"),
+		TagID:    q.Get("tag"),
+		FolderID: q.Get("folder"),
+		Sort:     q.Get("sort"),
+		View:     q.Get("view"),
+	}
+	if !db.ValidConversationView(opts.View) {
+		writeError(w, http.StatusBadRequest, "view must be one of archived, trash, all")
+		return
+	}
+	if !db.ValidConversationSort(opts.Sort) {
+		writeError(w, http.StatusBadRequest, "sort must be one of updated_desc, updated_asc, created_desc, created_asc")
+		return
+	}
+	var ok bool
+	if opts.Limit, opts.From, opts.To, ok = parsePageParams(w, r); !ok {
+		return
+	}
+
+	convos, next, err := h.db.ListConversationsPage(currentUser(r).ID, opts)
+	if errors.Is(err, db.ErrInvalidCursor) {
+		writeError(w, http.StatusBadRequest, "Invalid cursor")
+		return
+	}
 	if err != nil {
 		h.logger.Error("list conversations failed", "error", err)
 		writeError(w, http.StatusInternalServerError, "Failed to list conversations")
@@ -105,9 +170,32 @@ func (h *Handler) ListConversations(w http.ResponseWriter, r *http.Request) {
 	}
 	writeJSON(w, http.StatusOK, map[string]interface{}{
 		"conversations": convos,
+		"next_cursor":   next,
 	})
 }
 
+func parsePageParams(w http.ResponseWriter, r *http.Request) (limit int, from, to *time.Time, ok bool) {
+	q := r.URL.Query()
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			writeError(w, http.StatusBadRequest, "limit must be a positive integer")
+			return 0, nil, nil, false
+		}
+		limit = n
+	}
+	var err error
+	if from, err = parseDateParam(q.Get("from"), false); err != nil {
+		writeError(w, http.StatusBadRequest, "from must be a date (2006-01-02) or RFC 3339 timestamp")
+		return 0, nil, nil, false
+	}
+	if to, err = parseDateParam(q.Get("to"), true); err != nil {
+		writeError(w, http.StatusBadRequest, "to must be a date (2006-01-02) or RFC 3339 timestamp")
+		return 0, nil, nil, false
+	}
+	return limit, from, to, true
+}
+
 func (h *Handler) CreateConversation(w http.ResponseWriter, r *http.Request) {
 	var req struct {
 		Title string `json:"title"`
@@ -120,7 +208,7 @@ func (h *Handler) CreateConversation(w http.ResponseWriter, r *http.Request) {
 	}
 
 	id := uuid.New().String()
-	convo, err := h.db.CreateConversation(id, req.Title, req.Model)
+	convo, err := h.db.CreateConversation(id, currentUser(r).ID, req.Title, req.Model)
 	if err != nil {
 		h.logger.Error("create conversation failed", "error", err)
 		writeError(w, http.StatusInternalServerError, "Failed to create conversation")
@@ -132,19 +220,24 @@ func (h *Handler) CreateConversation(w http.ResponseWriter, r *http.Request) {
 
 func (h *Handler) GetConversation(w http.ResponseWriter, r *http.Request) {
 	id := r.PathValue("id")
-	convo, err := h.db.GetConversation(id)
+	userID := currentUser(r).ID
+	convo, err := h.db.GetConversation(id, userID)
 	if err != nil {
 		writeError(w, http.StatusNotFound, "Conversation not found")
 		return
 	}
 
-	msgs, _ := h.db.GetMessages(id)
+	msgs, _ := h.db.GetBranch(id, userID, convo.ActiveMessageID)
 	if msgs == nil {
 		msgs = []db.Message{}
 	}
+	if err := h.db.LoadAttachments(msgs, false); err != nil {
+		h.logger.Error("load attachments failed", "error", err)
+	}
 
 	writeJSON(w, http.StatusOK, map[string]interface{}{
 		"conversation": convo,
+		"settings":     h.conversationSettings(id),
 		"messages":     msgs,
 	})
 }
@@ -152,11 +245,47 @@ func (h *Handler) GetConversation(w http.ResponseWriter, r *http.Request) {
 func (h *Handler) UpdateConversation(w http.ResponseWriter, r *http.Request) {
 	id := r.PathValue("id")
 	var req struct {
-		Title string `json:"title"`
+		Title    *string         `json:"title"`
+		FolderID *string         `json:"folder_id"`
+		Tags     *[]string       `json:"tags"`
+		Pinned   *bool           `json:"pinned"`
+		Archived *bool           `json:"archived"`
+		Settings *settingsUpdate `json:"settings"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	var settings *db.ConversationSettings
+	if req.Settings != nil {
+		var err error
+		settings, err = req.Settings.apply(h.conversationSettings(id))
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "Invalid settings: "+err.Error())
+			return
+		}
 	}
-	json.NewDecoder(r.Body).Decode(&req)
 
-	if err := h.db.UpdateConversationTitle(id, req.Title); err != nil {
+	err := h.db.UpdateConversation(id, currentUser(r).ID, db.ConversationUpdate{
+		Title:    req.Title,
+		FolderID: req.FolderID,
+		TagIDs:   req.Tags,
+		Pinned:   req.Pinned,
+		Archived: req.Archived,
+		Settings: settings,
+	})
+	switch {
+	case errors.Is(err, db.ErrNotFound):
+		writeError(w, http.StatusNotFound, "Conversation not found")
+		return
+	case errors.Is(err, db.ErrFolderNotFound):
+		writeError(w, http.StatusBadRequest, "Folder not found")
+		return
+	case errors.Is(err, db.ErrTagNotFound):
+		writeError(w, http.StatusBadRequest, "Tag not found")
+		return
+	case err != nil:
 		writeError(w, http.StatusInternalServerError, "Failed to update conversation")
 		return
 	}
@@ -165,16 +294,68 @@ func (h *Handler) UpdateConversation(w http.ResponseWriter, r *http.Request) {
 
 func (h *Handler) DeleteConversation(w http.ResponseWriter, r *http.Request) {
 	id := r.PathValue("id")
-	if err := h.db.DeleteConversation(id); err != nil {
+	userID := currentUser(r).ID
+
+	status := "trashed"
+	var err error
+	if r.URL.Query().Get("permanent") == "true" {
+		status = "deleted"
+		err = h.db.DeleteConversation(id, userID)
+	} else {
+		err = h.db.TrashConversation(id, userID)
+	}
+	if err != nil {
+		if errors.Is(err, db.ErrNotFound) {
+			writeError(w, http.StatusNotFound, "Conversation not found")
+			return
+		}
 		writeError(w, http.StatusInternalServerError, "Failed to delete conversation")
 		return
 	}
-	writeJSON(w, http.StatusOK, map[string]string{"status": "deleted"})
+	h.generations.stop(id)
+	writeJSON(w, http.StatusOK, map[string]string{"status": status})
 }
 
 func (h *Handler) GetMessages(w http.ResponseWriter, r *http.Request) {
 	id := r.PathValue("id")
-	msgs, err := h.db.GetMessages(id)
+	userID := currentUser(r).ID
+	convo, err := h.db.GetConversation(id, userID)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "Conversation not found")
+		return
+	}
+
+	q := r.URL.Query()
+	opts := db.MessageListOptions{
+		Cursor: q.Get("cursor"),
+		Role:   q.Get("role"),
+		Model:  q.Get("model"),
+	}
+	switch q.Get("sort") {
+	case "", "asc":
+	case "desc":
+		opts.Desc = true
+	default:
+		writeError(w, http.StatusBadRequest, "sort must be asc or desc")
+		return
+	}
+	var ok bool
+	if opts.Limit, opts.From, opts.To, ok = parsePageParams(w, r); !ok {
+		return
+	}
+
+	var msgs []db.Message
+	var next string
+	if q.Get("tree") != "true" {
+		opts.LeafID = convo.ActiveMessageID
+	}
+	if q.Get("tree") == "true" || opts.LeafID != "" {
+		msgs, next, err = h.db.ListMessagesPage(id, userID, opts)
+	}
+	if errors.Is(err, db.ErrInvalidCursor) {
+		writeError(w, http.StatusBadRequest, "Invalid cursor")
+		return
+	}
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, "Failed to get messages")
 		return
@@ -182,8 +363,13 @@ func (h *Handler) GetMessages(w http.ResponseWriter, r *http.Request) {
 	if msgs == nil {
 		msgs = []db.Message{}
 	}
+	if err := h.db.LoadAttachments(msgs, false); err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to get messages")
+		return
+	}
 	writeJSON(w, http.StatusOK, map[string]interface{}{
-		"messages": msgs,
+		"messages":    msgs,
+		"next_cursor": next,
 	})
 }
 
@@ -192,30 +378,81 @@ type ChatAPIRequest struct {
 	Model          string          `json:"model"`
 	Message        string          `json:"message"`
 	SystemPrompt   string          `json:"system_prompt,omitempty"`
-	Options        *ollama.Options `json:"options,omitempty"`
+	Options        json.RawMessage `json:"options,omitempty"`
+	KeepAlive      json.RawMessage `json:"keep_alive,omitempty"`
+	Tools          []string        `json:"tools,omitempty"`
+	Images         []string        `json:"images,omitempty"`
+	Format         json.RawMessage `json:"format,omitempty"`
+	FormatRetries  int             `json:"format_retries,omitempty"`
 }
 
 func (h *Handler) ChatStream(w http.ResponseWriter, r *http.Request) {
-	var req ChatAPIRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeError(w, http.StatusBadRequest, "Invalid request body")
+	req, uploads, ok := h.decodeChatRequest(w, r)
+	if !ok {
 		return
 	}
 
-	if req.Message == "" || req.Model == "" {
+	if req.Message == "" || (req.Model == "" && req.ConversationID == "") {
 		writeError(w, http.StatusBadRequest, "Message and model are required")
 		return
 	}
+	toolDefs, ok := h.toolDefinitions(w, req.Tools)
+	if !ok {
+		return
+	}
+	format, ok := h.outputFormat(w, req.Format, req.FormatRetries)
+	if !ok {
+		return
+	}
+	options, keepAlive, ok := h.generationOptions(w, req.Options, req.KeepAlive)
+	if !ok {
+		return
+	}
+
+	userID := currentUser(r).ID
+	attachments, ok := h.buildAttachments(w, userID, uploads)
+	if !ok {
+		return
+	}
+	var settings *db.ConversationSettings
+	if req.ConversationID != "" {
+		convo, err := h.db.GetConversation(req.ConversationID, userID)
+		if err != nil || convo.DeletedAt != nil {
+			writeError(w, http.StatusNotFound, "Conversation not found")
+			return
+		}
+		settings = h.conversationSettings(req.ConversationID)
+		if req.Model == "" && settings != nil {
+			req.Model = settings.Model
+		}
+		if req.Model == "" {
+			req.Model = convo.Model
+		}
+		if req.Model == "" {
+			writeError(w, http.StatusBadRequest, "Model is required")
+			return
+		}
+	}
 
 	if req.ConversationID == "" {
 		id := uuid.New().String()
-		_, err := h.db.CreateConversation(id, "New Chat", req.Model)
+		_, err := h.db.CreateConversation(id, userID, "New Chat", req.Model)
 		if err != nil {
 			writeError(w, http.StatusInternalServerError, "Failed to create conversation")
 			return
 		}
 		req.ConversationID = id
 
+		settings = &db.ConversationSettings{
+			SystemPrompt: req.SystemPrompt,
+			Model:        req.Model,
+			Options:      encodeOptions(options),
+		}
+		if err := h.db.SaveConversationSettings(id, settings); err != nil {
+			h.logger.Error("save conversation settings failed", "error", err)
+			settings = nil
+		}
+
 		if req.SystemPrompt != "" {
 			systemMsg := &db.Message{
 				ID:             uuid.New().String(),
@@ -228,12 +465,38 @@ func (h *Handler) ChatStream(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	ctx, finish, ok := h.generations.start(r.Context(), req.ConversationID)
+	if !ok {
+		writeError(w, http.StatusConflict, "A response is already being generated for this conversation")
+		return
+	}
+	defer finish()
+
+	convo, err := h.db.GetConversation(req.ConversationID, userID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to load conversation")
+		return
+	}
+
+	params := replyParams{
+		conversationID: req.ConversationID,
+		model:          req.Model,
+		options:        options,
+		keepAlive:      keepAlive,
+		tools:          toolDefs,
+		format:         format,
+	}
+	params.applySettings(settings)
+
 	userMsg := &db.Message{
-		ID:             uuid.New().String(),
-		ConversationID: req.ConversationID,
-		Role:           "user",
-		Content:        req.Message,
-		CreatedAt:      time.Now(),
+		ID:              uuid.New().String(),
+		ConversationID:  req.ConversationID,
+		ParentID:        convo.ActiveMessageID,
+		Role:            "user",
+		Content:         req.Message,
+		Attachments:     attachments,
+		SettingsVersion: params.settingsVersion,
+		CreatedAt:       time.Now(),
 	}
 	if err := h.db.CreateMessage(userMsg); err != nil {
 		h.logger.Error("save user message failed", "error", err)
@@ -241,13 +504,156 @@ func (h *Handler) ChatStream(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	history, _ := h.db.GetMessages(req.ConversationID)
+	history, _ := h.db.GetBranch(req.ConversationID, userID, userMsg.ID)
+	params.history = history
+
+	reply := h.streamReply(ctx, w, r, params)
+
+	if reply != nil && !reply.Interrupted && len(history) <= 1 {
+		go func() {
+			title, err := provider.GenerateTitle(context.Background(), h.llm, req.Model, req.Message)
+			if err != nil {
+				h.logger.Warn("auto title failed", "error", err)
+				return
+			}
+			title = strings.TrimSpace(title)
+			if title != "" {
+				h.db.UpdateConversationTitle(req.ConversationID, userID, title)
+			}
+		}()
+	}
+}
+
+func (h *Handler) RegenerateResponse(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Model     string          `json:"model,omitempty"`
+		Options   json.RawMessage `json:"options,omitempty"`
+		KeepAlive json.RawMessage `json:"keep_alive,omitempty"`
+		Tools     []string        `json:"tools,omitempty"`
+
+		Format        json.RawMessage `json:"format,omitempty"`
+		FormatRetries int             `json:"format_retries,omitempty"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+	}
+	toolDefs, ok := h.toolDefinitions(w, req.Tools)
+	if !ok {
+		return
+	}
+	format, ok := h.outputFormat(w, req.Format, req.FormatRetries)
+	if !ok {
+		return
+	}
+	options, keepAlive, ok := h.generationOptions(w, req.Options, req.KeepAlive)
+	if !ok {
+		return
+	}
+
+	id := r.PathValue("id")
+	userID := currentUser(r).ID
+	convo, err := h.db.GetConversation(id, userID)
+	if err != nil || convo.DeletedAt != nil {
+		writeError(w, http.StatusNotFound, "Conversation not found")
+		return
+	}
+
+	ctx, finish, ok := h.generations.start(r.Context(), id)
+	if !ok {
+		writeError(w, http.StatusConflict, "A response is already being generated for this conversation")
+		return
+	}
+	defer finish()
+
+	history, err := h.db.GetBranch(id, userID, convo.ActiveMessageID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to get messages")
+		return
+	}
+
+	var previous *db.Message
+	for n := len(history); n > 0 && (history[n-1].Role == "assistant" || history[n-1].Role == "tool"); n-- {
+		if history[n-1].Role == "assistant" {
+			previous = &history[n-1]
+		}
+		history = history[:n-1]
+	}
+	if n := len(history); n == 0 || history[n-1].Role != "user" {
+		writeError(w, http.StatusBadRequest, "Conversation has no user message to respond to")
+		return
+	}
+
+	settings := h.conversationSettings(id)
+	model := req.Model
+	if model == "" && previous != nil {
+		model = previous.Model
+	}
+	if model == "" && settings != nil {
+		model = settings.Model
+	}
+	if model == "" {
+		model = convo.Model
+	}
+	if model == "" {
+		writeError(w, http.StatusBadRequest, "Model is required")
+		return
+	}
+
+	params := replyParams{
+		conversationID: id,
+		model:          model,
+		options:        options,
+		keepAlive:      keepAlive,
+		tools:          toolDefs,
+		format:         format,
+		history:        history,
+	}
+	params.applySettings(settings)
+	h.streamReply(ctx, w, r, params)
+}
+
+type replyParams struct {
+	conversationID  string
+	model           string
+	options         *ollama.Options
+	keepAlive       json.RawMessage
+	systemPrompt    *string
+	tools           []ollama.Tool
+	format          *outputFormat
+	history         []db.Message
+	settingsVersion int
+}
+
+const maxToolRounds = 5
+
+func chatMessage(m db.Message) ollama.ChatMessage {
+	msg := ollama.ChatMessage{
+		Role:     m.Role,
+		Content:  m.Content,
+		ToolName: m.ToolName,
+	}
+	for _, a := range m.Attachments {
+		if len(a.Data) > 0 {
+			msg.Images = append(msg.Images, base64.StdEncoding.EncodeToString(a.Data))
+		}
+	}
+	if len(m.ToolCalls) > 0 {
+		json.Unmarshal(m.ToolCalls, &msg.ToolCalls)
+	}
+	return msg
+}
+
+func (h *Handler) streamReply(ctx context.Context, w http.ResponseWriter, r *http.Request, p replyParams) *db.Message {
+	p.history = withSystemPrompt(p.history, p.systemPrompt)
+	if err := h.db.LoadAttachments(p.history, true); err != nil {
+		h.logger.Error("load attachments failed", "error", err)
+	}
 	var chatMessages []ollama.ChatMessage
-	for _, m := range history {
-		chatMessages = append(chatMessages, ollama.ChatMessage{
-			Role:    m.Role,
-			Content: m.Content,
-		})
+	for _, m := range p.history {
+		chatMessages = append(chatMessages, chatMessage(m))
 	}
 
 	w.Header().Set("Content-Type", "text/event-stream")
@@ -258,99 +664,253 @@ func (h *Handler) ChatStream(w http.ResponseWriter, r *http.Request) {
 	flusher, ok := w.(http.Flusher)
 	if !ok {
 		writeError(w, http.StatusInternalServerError, "Streaming not supported")
-		return
+		return nil
+	}
+
+	userID := currentUser(r).ID
+	var stored storedSummary
+	if convo, err := h.db.GetConversation(p.conversationID, userID); err == nil {
+		stored = summaryFor(convo, p.history)
 	}
 
-	initData, _ := json.Marshal(map[string]string{
-		"type":            "init",
-		"conversation_id": req.ConversationID,
+	fit := h.fitContext(ctx, p.model, p.options, chatMessages, stored)
+	if fit.trimmed > 0 {
+		h.logger.Info("trimmed chat history to fit context window",
+			"conversation_id", p.conversationID,
+			"model", p.model,
+			"trimmed", fit.trimmed,
+			"summarized", fit.summarized,
+		)
+	}
+
+	initData, _ := json.Marshal(map[string]interface{}{
+		"type":             "init",
+		"conversation_id":  p.conversationID,
+		"trimmed_messages": fit.trimmed,
+		"summarized":       fit.summarized,
 	})
 	fmt.Fprintf(w, "data: %s\n\n", initData)
 	flusher.Flush()
 
-	var fullResponse strings.Builder
-	var totalTokens int
-	var totalDuration float64
-
-	chatReq := &ollama.ChatRequest{
-		Model:    req.Model,
-		Messages: chatMessages,
-		Options:  req.Options,
+	send := func(event interface{}) {
+		data, _ := json.Marshal(event)
+		fmt.Fprintf(w, "data: %s\n\n", data)
+		flusher.Flush()
 	}
 
-	err := h.ollama.ChatStream(chatReq, func(resp ollama.ChatResponse) error {
-		chunk := map[string]interface{}{
-			"type":    "chunk",
-			"content": resp.Message.Content,
-			"done":    resp.Done,
+	messages := fit.messages
+	parentID := p.history[len(p.history)-1].ID
+	formatAttempts := 0
+	for round := 0; ; round++ {
+		var fullResponse strings.Builder
+		var toolCalls []ollama.ToolCall
+		var totalTokens int
+		var totalDuration float64
+		var final map[string]interface{}
+
+		chatReq := &ollama.ChatRequest{
+			Model:     p.model,
+			Messages:  messages,
+			Options:   p.options,
+			KeepAlive: p.keepAlive,
 		}
-
-		if resp.Done {
-			totalTokens = resp.EvalCount + resp.PromptEvalCount
-			totalDuration = float64(resp.TotalDuration) / 1e9
-			chunk["total_tokens"] = totalTokens
-			chunk["eval_count"] = resp.EvalCount
-			chunk["duration"] = totalDuration
+		if round < maxToolRounds {
+			chatReq.Tools = p.tools
+		}
+		if p.format != nil {
+			chatReq.Format = p.format.raw
 		}
 
-		fullResponse.WriteString(resp.Message.Content)
+		err := h.llm.ChatStream(ctx, chatReq, func(resp ollama.ChatResponse) error {
+			toolCalls = append(toolCalls, resp.Message.ToolCalls...)
+			done := resp.Done && len(toolCalls) == 0
+			chunk := map[string]interface{}{
+				"type":    "chunk",
+				"content": resp.Message.Content,
+				"done":    done,
+			}
 
-		data, _ := json.Marshal(chunk)
-		fmt.Fprintf(w, "data: %s\n\n", data)
-		flusher.Flush()
-		return nil
-	})
+			if resp.Done {
+				totalTokens = resp.EvalCount + resp.PromptEvalCount
+				totalDuration = float64(resp.TotalDuration) / 1e9
+			}
+			if done {
+				chunk["total_tokens"] = totalTokens
+				chunk["eval_count"] = resp.EvalCount
+				chunk["duration"] = totalDuration
+			}
 
-	if err != nil {
-		h.logger.Error("chat stream failed", "error", err)
-		errData, _ := json.Marshal(map[string]string{
-			"type":  "error",
-			"error": err.Error(),
+			fullResponse.WriteString(resp.Message.Content)
+			if done && p.format != nil {
+				final = chunk
+				chunk = map[string]interface{}{"type": "chunk", "content": resp.Message.Content, "done": false}
+				final["content"] = ""
+				done = false
+			}
+			if resp.Message.Content != "" || done {
+				send(chunk)
+			}
+			return nil
 		})
-		fmt.Fprintf(w, "data: %s\n\n", errData)
-		flusher.Flush()
-		return
-	}
 
-	assistantMsg := &db.Message{
-		ID:             uuid.New().String(),
-		ConversationID: req.ConversationID,
-		Role:           "assistant",
-		Content:        fullResponse.String(),
-		Model:          req.Model,
-		TokensUsed:     totalTokens,
-		Duration:       totalDuration,
-		CreatedAt:      time.Now(),
-	}
-	h.db.CreateMessage(assistantMsg)
-	h.db.TouchConversation(req.ConversationID)
+		stopped := errors.Is(context.Cause(ctx), errGenerationStopped)
+		interrupted := err != nil && (stopped || r.Context().Err() != nil)
+		if err != nil && !interrupted {
+			h.logger.Error("chat stream failed", "error", err)
+			send(map[string]string{
+				"type":  "error",
+				"error": err.Error(),
+			})
+			return nil
+		}
 
-	if len(history) <= 1 {
-		go func() {
-			title, err := h.ollama.GenerateTitle(req.Model, req.Message)
+		if stopped {
+			h.logger.Info("chat stream stopped", "conversation_id", p.conversationID)
+		} else if interrupted {
+			h.logger.Info("chat stream interrupted by client", "conversation_id", p.conversationID)
+		}
+		if interrupted && fullResponse.Len() == 0 {
+			if stopped {
+				writeStopped(w, flusher, p.conversationID, "")
+			}
+			return nil
+		}
+		if interrupted {
+			toolCalls = nil
+		}
+
+		if final != nil {
+			formatAttempts++
+			status := map[string]interface{}{"valid": true, "attempts": formatAttempts}
+			if err := p.format.check(fullResponse.String()); err != nil {
+				if formatAttempts <= p.format.retries {
+					send(map[string]interface{}{
+						"type":    "format_retry",
+						"attempt": formatAttempts,
+						"error":   err.Error(),
+					})
+					messages = append(messages,
+						ollama.ChatMessage{Role: "assistant", Content: fullResponse.String()},
+						ollama.ChatMessage{Role: "user", Content: p.format.retryPrompt(err)},
+					)
+					continue
+				}
+				status["valid"] = false
+				status["error"] = err.Error()
+			}
+			final["format"] = status
+			send(final)
+		}
+
+		assistantMsg := &db.Message{
+			ID:              uuid.New().String(),
+			ConversationID:  p.conversationID,
+			ParentID:        parentID,
+			Role:            "assistant",
+			Content:         fullResponse.String(),
+			Model:           p.model,
+			TokensUsed:      totalTokens,
+			Duration:        totalDuration,
+			Interrupted:     interrupted,
+			SettingsVersion: p.settingsVersion,
+			Options:         encodeOptions(p.options),
+			CreatedAt:       time.Now(),
+		}
+		if len(toolCalls) > 0 {
+			assistantMsg.ToolCalls, _ = json.Marshal(toolCalls)
+		}
+		if err := h.db.CreateMessage(assistantMsg); err != nil {
+			h.logger.Error("save assistant message failed", "error", err)
+			return nil
+		}
+		h.db.TouchConversation(p.conversationID)
+		parentID = assistantMsg.ID
+
+		if len(toolCalls) == 0 {
+			if stopped {
+				writeStopped(w, flusher, p.conversationID, assistantMsg.ID)
+			} else {
+				go h.refreshSummary(p.conversationID, userID, p.model, fit.summaryStale)
+			}
+			return assistantMsg
+		}
+
+		messages = append(messages, chatMessage(*assistantMsg))
+		for _, call := range toolCalls {
+			send(map[string]interface{}{
+				"type":       "tool_call",
+				"message_id": assistantMsg.ID,
+				"name":       call.Function.Name,
+				"arguments":  call.Function.Arguments,
+			})
+
+			result, err := h.tools.Run(ctx, call.Function.Name, tools.Invocation{
+				UserID:         userID,
+				ConversationID: p.conversationID,
+				Args:           call.Function.Arguments,
+			})
 			if err != nil {
-				h.logger.Warn("auto title failed", "error", err)
-				return
+				result = "error: " + err.Error()
 			}
-			title = strings.TrimSpace(title)
-			if title != "" {
-				h.db.UpdateConversationTitle(req.ConversationID, title)
+
+			toolMsg := &db.Message{
+				ID:             uuid.New().String(),
+				ConversationID: p.conversationID,
+				ParentID:       parentID,
+				Role:           "tool",
+				Content:        result,
+				ToolName:       call.Function.Name,
+				CreatedAt:      time.Now(),
 			}
-		}()
+			if err := h.db.CreateMessage(toolMsg); err != nil {
+				h.logger.Error("save tool message failed", "error", err)
+				return nil
+			}
+			parentID = toolMsg.ID
+			messages = append(messages, chatMessage(*toolMsg))
+
+			send(map[string]interface{}{
+				"type":       "tool_result",
+				"message_id": toolMsg.ID,
+				"name":       call.Function.Name,
+				"content":    result,
+				"error":      err != nil,
+			})
+		}
+
+		if ctx.Err() != nil {
+			if errors.Is(context.Cause(ctx), errGenerationStopped) {
+				writeStopped(w, flusher, p.conversationID, parentID)
+			}
+			return nil
+		}
 	}
 }
 
+func writeStopped(w http.ResponseWriter, flusher http.Flusher, conversationID, messageID string) {
+	event := map[string]string{
+		"type":            "stopped",
+		"conversation_id": conversationID,
+	}
+	if messageID != "" {
+		event["message_id"] = messageID
+	}
+	data, _ := json.Marshal(event)
+	fmt.Fprintf(w, "data: %s\n\n", data)
+	flusher.Flush()
+}
+
 func (h *Handler) GetStats(w http.ResponseWriter, r *http.Request) {
-	stats, err := h.db.GetConversationStats()
+	stats, err := h.db.GetConversationStats(currentUser(r).ID)
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, "Failed to get stats")
 		return
 	}
 
-	ollamaOK := h.ollama.IsHealthy()
+	ollamaOK := h.llm.IsHealthy(r.Context())
 	stats["ollama_connected"] = ollamaOK
 
-	models, err := h.ollama.ListModels()
+	models, err := h.llm.ListModels(r.Context())
 	if err == nil {
 		stats["models_count"] = len(models)
 	}
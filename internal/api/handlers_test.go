@@ -0,0 +1,183 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ifauzeee/Zee-AI/internal/db"
+	"github.com/ifauzeee/Zee-AI/internal/ollama"
+)
+
+type blockingLLM struct {
+	block   bool
+	started chan struct{}
+
+	mu       sync.Mutex
+	requests []ollama.ChatRequest
+}
+
+func newBlockingLLM(block bool) *blockingLLM {
+	return &blockingLLM{block: block, started: make(chan struct{}, 1)}
+}
+
+func (l *blockingLLM) ListModels(ctx context.Context) ([]ollama.Model, error) {
+	return []ollama.Model{{Name: "llama3:latest"}}, nil
+}
+
+func (l *blockingLLM) Chat(ctx context.Context, req *ollama.ChatRequest) (*ollama.ChatResponse, error) {
+	return &ollama.ChatResponse{Message: ollama.ChatMessage{Role: "assistant", Content: "Title"}, Done: true}, nil
+}
+
+func (l *blockingLLM) ChatStream(ctx context.Context, req *ollama.ChatRequest, onChunk func(ollama.ChatResponse) error) error {
+	l.mu.Lock()
+	l.requests = append(l.requests, *req)
+	l.mu.Unlock()
+
+	if err := onChunk(ollama.ChatResponse{Message: ollama.ChatMessage{Role: "assistant", Content: "partial"}}); err != nil {
+		return err
+	}
+	if l.block {
+		l.started <- struct{}{}
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	return onChunk(ollama.ChatResponse{Message: ollama.ChatMessage{Role: "assistant", Content: " answer"}, Done: true, EvalCount: 2})
+}
+
+func (l *blockingLLM) IsHealthy(ctx context.Context) bool { return true }
+
+func (l *blockingLLM) waitStarted(t *testing.T) {
+	t.Helper()
+	select {
+	case <-l.started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("generation never started")
+	}
+}
+
+func startStream(t *testing.T, router http.Handler, path string, body interface{}) (*httptest.ResponseRecorder, context.CancelFunc, <-chan struct{}) {
+	t.Helper()
+
+	data, _ := json.Marshal(body)
+	ctx, cancel := context.WithCancel(context.Background())
+	req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(data)).WithContext(ctx)
+	req.Header.Set("Authorization", "Bearer s3cret")
+	rec := httptest.NewRecorder()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		router.ServeHTTP(rec, req)
+	}()
+	t.Cleanup(cancel)
+	return rec, cancel, done
+}
+
+func waitDone(t *testing.T, done <-chan struct{}) {
+	t.Helper()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("stream did not finish")
+	}
+}
+
+func assistantMessages(t *testing.T, database *db.DB, conversationID string) []db.Message {
+	t.Helper()
+	msgs, err := database.GetMessages(conversationID, "admin-id")
+	if err != nil {
+		t.Fatalf("get messages: %v", err)
+	}
+	var out []db.Message
+	for _, m := range msgs {
+		if m.Role == "assistant" {
+			out = append(out, m)
+		}
+	}
+	return out
+}
+
+func TestClientDisconnectKeepsPartialReply(t *testing.T) {
+	llm := newBlockingLLM(true)
+	router, database := newTestRouterWithProvider(t, llm)
+	database.CreateConversation("c1", "admin-id", "Disconnect", "llama3")
+
+	_, cancel, done := startStream(t, router, "/api/chat", map[string]string{
+		"conversation_id": "c1",
+		"message":         "tell me a long story",
+	})
+	llm.waitStarted(t)
+	cancel()
+	waitDone(t, done)
+
+	replies := assistantMessages(t, database, "c1")
+	if len(replies) != 1 || replies[0].Content != "partial" || !replies[0].Interrupted {
+		t.Fatalf("stored replies = %+v, want one interrupted partial reply", replies)
+	}
+	if c, _ := database.GetConversation("c1", "admin-id"); c.ActiveMessageID != replies[0].ID || c.Title != "Disconnect" {
+		t.Fatalf("conversation = %+v", c)
+	}
+	rec := doRequest(t, router, http.MethodPost, "/api/conversations/c1/stop", map[string]string{"Authorization": "Bearer s3cret"}, nil)
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("stop after disconnect = %d, want 404 once the generation is released", rec.Code)
+	}
+}
+
+func TestRegenerateCreatesSiblingReply(t *testing.T) {
+	llm := newBlockingLLM(false)
+	router, database := newTestRouterWithProvider(t, llm)
+	headers := map[string]string{"Authorization": "Bearer s3cret"}
+	database.CreateConversation("c1", "admin-id", "Regenerate", "llama3")
+	for _, m := range []db.Message{
+		{ID: "q1", Role: "user", Content: "pick a number"},
+		{ID: "a1", ParentID: "q1", Role: "assistant", Content: "seven", Model: "llama3"},
+	} {
+		m.ConversationID = "c1"
+		m.CreatedAt = time.Now()
+		if err := database.CreateMessage(&m); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	rec := doRequest(t, router, http.MethodPost, "/api/conversations/c1/regenerate", headers, map[string]string{"model": "mistral"})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("regenerate = %d: %s", rec.Code, rec.Body)
+	}
+	llm.mu.Lock()
+	sent := llm.requests[0]
+	llm.mu.Unlock()
+	if sent.Model != "mistral" || len(sent.Messages) != 1 || sent.Messages[0].Content != "pick a number" {
+		t.Fatalf("regenerate request = %+v", sent)
+	}
+
+	replies := assistantMessages(t, database, "c1")
+	if len(replies) != 2 || replies[0].ID != "a1" || replies[0].Content != "seven" {
+		t.Fatalf("stored replies = %+v, want the original kept", replies)
+	}
+	fresh := replies[1]
+	if fresh.ParentID != "q1" || fresh.Content != "partial answer" || fresh.Model != "mistral" || fresh.Interrupted {
+		t.Fatalf("regenerated reply = %+v", fresh)
+	}
+	if c, _ := database.GetConversation("c1", "admin-id"); c.ActiveMessageID != fresh.ID {
+		t.Fatalf("active message = %s, want %s", c.ActiveMessageID, fresh.ID)
+	}
+	if branches, _ := database.ListBranches("c1", "admin-id"); len(branches) != 2 {
+		t.Fatalf("branches = %+v", branches)
+	}
+
+	database.CreateConversation("empty", "admin-id", "Empty", "llama3")
+	rec = doRequest(t, router, http.MethodPost, "/api/conversations/empty/regenerate", headers, nil)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("regenerate without a user message = %d, want 400", rec.Code)
+	}
+	database.TrashConversation("c1", "admin-id")
+	rec = doRequest(t, router, http.MethodPost, "/api/conversations/c1/regenerate", headers, nil)
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("regenerate in trash = %d, want 404", rec.Code)
+	}
+}
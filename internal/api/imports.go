@@ -0,0 +1,571 @@
+package api
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ifauzeee/Zee-AI/internal/db"
+)
+
+const maxImportSize = 256 << 20
+
+var maxImportExpandedSize int64 = 1 << 30
+
+var errImportTooLarge = errors.New("archive expands beyond the import size limit")
+
+const (
+	importSourceChatGPT   = "chatgpt"
+	importSourceOpenWebUI = "openwebui"
+	importSourceZee       = exportFormatName
+)
+
+type importedMessage struct {
+	ID        string
+	ParentID  string
+	Role      string
+	Content   string
+	Model     string
+	Tokens    int
+	Duration  float64
+	ToolCalls json.RawMessage
+	ToolName  string
+	CreatedAt time.Time
+}
+
+type importedConversation struct {
+	Source    string
+	SourceID  string
+	Title     string
+	Model     string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	CurrentID string
+	Messages  []importedMessage
+}
+
+type importResult struct {
+	SourceID       string `json:"source_id"`
+	Source         string `json:"source,omitempty"`
+	Title          string `json:"title,omitempty"`
+	Status         string `json:"status"`
+	ConversationID string `json:"conversation_id,omitempty"`
+	Messages       int    `json:"messages,omitempty"`
+	Error          string `json:"error,omitempty"`
+}
+
+func (h *Handler) ImportConversations(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportSize)
+
+	var data []byte
+	var err error
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		file, _, ferr := r.FormFile("file")
+		if ferr != nil {
+			writeError(w, http.StatusBadRequest, "Multipart uploads must include a file field")
+			return
+		}
+		defer file.Close()
+		data, err = io.ReadAll(file)
+	} else {
+		data, err = io.ReadAll(r.Body)
+	}
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Failed to read upload")
+		return
+	}
+
+	docs, err := importDocuments(data)
+	if errors.Is(err, errImportTooLarge) {
+		writeError(w, http.StatusRequestEntityTooLarge, "Invalid upload: "+err.Error())
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid upload: "+err.Error())
+		return
+	}
+
+	userID := currentUser(r).ID
+	results := []importResult{}
+	for _, doc := range docs {
+		convo, err := parseImport(doc)
+		if err != nil {
+			results = append(results, importResult{SourceID: importHash(doc), Status: "failed", Error: err.Error()})
+			continue
+		}
+		results = append(results, h.importConversation(userID, convo))
+	}
+
+	summary := map[string]int{"imported": 0, "skipped": 0, "failed": 0}
+	for _, res := range results {
+		summary[res.Status]++
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"summary": summary,
+		"results": results,
+	})
+}
+
+func (h *Handler) importConversation(userID string, ic *importedConversation) importResult {
+	res := importResult{SourceID: ic.SourceID, Source: ic.Source, Title: ic.Title}
+
+	if id, err := h.db.ImportedConversationID(userID, ic.Source, ic.SourceID); err == nil {
+		res.Status, res.ConversationID = "skipped", id
+		return res
+	}
+	if ic.Source == importSourceZee {
+		if _, err := h.db.GetConversation(ic.SourceID, userID); err == nil {
+			res.Status, res.ConversationID = "skipped", ic.SourceID
+			return res
+		}
+	}
+
+	convo, msgs := buildImport(userID, ic)
+	if err := h.db.ImportConversation(ic.Source, ic.SourceID, convo, msgs); err != nil {
+		h.logger.Error("import conversation failed", "source", ic.Source, "id", ic.SourceID, "error", err)
+		res.Status, res.Error = "failed", "Failed to save conversation"
+		return res
+	}
+	res.Status, res.ConversationID, res.Messages = "imported", convo.ID, len(msgs)
+	return res
+}
+
+func buildImport(userID string, ic *importedConversation) (*db.Conversation, []db.Message) {
+	ids := make(map[string]string, len(ic.Messages))
+	parents := make(map[string]string, len(ic.Messages))
+	for _, m := range ic.Messages {
+		ids[m.ID] = uuid.New().String()
+		parents[m.ID] = m.ParentID
+	}
+	depth := func(id string) int {
+		n := 0
+		for id = parents[id]; id != "" && n < len(parents); id = parents[id] {
+			n++
+		}
+		return n
+	}
+
+	sorted := append([]importedMessage(nil), ic.Messages...)
+	depths := make(map[string]int, len(sorted))
+	for _, m := range sorted {
+		depths[m.ID] = depth(m.ID)
+	}
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, b := sorted[i], sorted[j]
+		if !a.CreatedAt.Equal(b.CreatedAt) {
+			return a.CreatedAt.Before(b.CreatedAt)
+		}
+		if depths[a.ID] != depths[b.ID] {
+			return depths[a.ID] < depths[b.ID]
+		}
+		return a.ID < b.ID
+	})
+
+	msgs := make([]db.Message, 0, len(sorted))
+	for _, m := range sorted {
+		msgs = append(msgs, db.Message{
+			ID:         ids[m.ID],
+			ParentID:   ids[m.ParentID],
+			Role:       m.Role,
+			Content:    m.Content,
+			Model:      m.Model,
+			TokensUsed: m.Tokens,
+			Duration:   m.Duration,
+			ToolCalls:  m.ToolCalls,
+			ToolName:   m.ToolName,
+			CreatedAt:  m.CreatedAt,
+		})
+	}
+	active := ids[ic.CurrentID]
+	if active == "" && len(msgs) > 0 {
+		active = msgs[len(msgs)-1].ID
+	}
+	title := strings.TrimSpace(ic.Title)
+	if title == "" {
+		title = "Imported Chat"
+	}
+	return &db.Conversation{
+		ID:              uuid.New().String(),
+		UserID:          userID,
+		Title:           title,
+		Model:           ic.Model,
+		ActiveMessageID: active,
+		CreatedAt:       ic.CreatedAt,
+		UpdatedAt:       ic.UpdatedAt,
+	}, msgs
+}
+
+func importDocuments(data []byte) ([]json.RawMessage, error) {
+	if bytes.HasPrefix(data, []byte("PK\x03\x04")) {
+		zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			return nil, errors.New("not a valid zip archive")
+		}
+		var docs []json.RawMessage
+		remaining := maxImportExpandedSize
+		for _, f := range zr.File {
+			if f.FileInfo().IsDir() || path.Ext(f.Name) != ".json" {
+				continue
+			}
+			if f.UncompressedSize64 > uint64(remaining) {
+				return nil, errImportTooLarge
+			}
+			rc, err := f.Open()
+			if err != nil {
+				return nil, fmt.Errorf("failed to read %s", f.Name)
+			}
+			content, err := io.ReadAll(io.LimitReader(rc, remaining+1))
+			rc.Close()
+			if err != nil {
+				return nil, fmt.Errorf("failed to read %s", f.Name)
+			}
+			if int64(len(content)) > remaining {
+				return nil, errImportTooLarge
+			}
+			remaining -= int64(len(content))
+			found, err := splitDocuments(content)
+			if err != nil {
+				return nil, fmt.Errorf("%s is not valid JSON", f.Name)
+			}
+			docs = append(docs, found...)
+		}
+		return docs, nil
+	}
+
+	docs, err := splitDocuments(data)
+	if err != nil {
+		return nil, errors.New("expected a JSON export or a zip of JSON exports")
+	}
+	return docs, nil
+}
+
+func splitDocuments(data []byte) ([]json.RawMessage, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '[' {
+		var docs []json.RawMessage
+		err := json.Unmarshal(data, &docs)
+		return docs, err
+	}
+	var doc json.RawMessage
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	return []json.RawMessage{doc}, nil
+}
+
+func parseImport(doc json.RawMessage) (*importedConversation, error) {
+	var probe struct {
+		Format  string          `json:"format"`
+		Mapping json.RawMessage `json:"mapping"`
+		Chat    json.RawMessage `json:"chat"`
+	}
+	if err := json.Unmarshal(doc, &probe); err != nil {
+		return nil, errors.New("entry is not a JSON object")
+	}
+
+	var ic *importedConversation
+	var err error
+	switch {
+	case probe.Format == exportFormatName:
+		ic, err = parseZeeExport(doc)
+	case probe.Mapping != nil:
+		ic, err = parseChatGPT(doc)
+	case probe.Chat != nil:
+		ic, err = parseOpenWebUI(doc)
+	default:
+		return nil, errors.New("unrecognized export format")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if ic.SourceID == "" {
+		ic.SourceID = importHash(doc)
+	}
+	if len(ic.Messages) == 0 {
+		return nil, errors.New("conversation has no messages")
+	}
+	return ic, nil
+}
+
+func parseZeeExport(doc json.RawMessage) (*importedConversation, error) {
+	var export conversationExport
+	if err := json.Unmarshal(doc, &export); err != nil {
+		return nil, fmt.Errorf("invalid %s export: %w", exportFormatName, err)
+	}
+	if export.Version > exportFormatVersion {
+		return nil, fmt.Errorf("unsupported %s export version %d", exportFormatName, export.Version)
+	}
+
+	c := export.Conversation
+	ic := &importedConversation{
+		Source:    importSourceZee,
+		SourceID:  c.ID,
+		Title:     c.Title,
+		Model:     c.Model,
+		CreatedAt: c.CreatedAt,
+		UpdatedAt: c.UpdatedAt,
+		CurrentID: c.ActiveMessageID,
+	}
+	for _, m := range export.Messages {
+		if !validImportRole(m.Role) && m.Role != "tool" {
+			continue
+		}
+		ic.Messages = append(ic.Messages, importedMessage{
+			ID:        m.ID,
+			ParentID:  m.ParentID,
+			Role:      m.Role,
+			Content:   m.Content,
+			Model:     m.Model,
+			Tokens:    m.TokensUsed,
+			Duration:  m.Duration,
+			ToolCalls: m.ToolCalls,
+			ToolName:  m.ToolName,
+			CreatedAt: m.CreatedAt,
+		})
+	}
+	fillImportTimes(ic)
+	return ic, nil
+}
+
+type chatGPTNode struct {
+	ID      string `json:"id"`
+	Parent  string `json:"parent"`
+	Message *struct {
+		Author struct {
+			Role string `json:"role"`
+		} `json:"author"`
+		CreateTime *float64 `json:"create_time"`
+		Content    struct {
+			ContentType string            `json:"content_type"`
+			Parts       []json.RawMessage `json:"parts"`
+			Text        string            `json:"text"`
+		} `json:"content"`
+		Metadata struct {
+			ModelSlug string `json:"model_slug"`
+			IsHidden  bool   `json:"is_visually_hidden_from_conversation"`
+		} `json:"metadata"`
+	} `json:"message"`
+}
+
+func parseChatGPT(doc json.RawMessage) (*importedConversation, error) {
+	var export struct {
+		ID             string                 `json:"id"`
+		ConversationID string                 `json:"conversation_id"`
+		Title          string                 `json:"title"`
+		CreateTime     float64                `json:"create_time"`
+		UpdateTime     float64                `json:"update_time"`
+		CurrentNode    string                 `json:"current_node"`
+		DefaultModel   string                 `json:"default_model_slug"`
+		Mapping        map[string]chatGPTNode `json:"mapping"`
+	}
+	if err := json.Unmarshal(doc, &export); err != nil {
+		return nil, fmt.Errorf("invalid ChatGPT export: %w", err)
+	}
+
+	ic := &importedConversation{
+		Source:    importSourceChatGPT,
+		SourceID:  export.ConversationID,
+		Title:     export.Title,
+		Model:     export.DefaultModel,
+		CreatedAt: unixSeconds(export.CreateTime),
+		UpdatedAt: unixSeconds(export.UpdateTime),
+	}
+	if ic.SourceID == "" {
+		ic.SourceID = export.ID
+	}
+
+	kept := func(id string) bool {
+		n, ok := export.Mapping[id]
+		if !ok || n.Message == nil || n.Message.Metadata.IsHidden || !validImportRole(n.Message.Author.Role) {
+			return false
+		}
+		return chatGPTText(n) != ""
+	}
+	keptAncestor := func(id string) string {
+		for seen := 0; id != "" && seen <= len(export.Mapping); seen++ {
+			if kept(id) {
+				return id
+			}
+			id = export.Mapping[id].Parent
+		}
+		return ""
+	}
+
+	for id, n := range export.Mapping {
+		if !kept(id) {
+			continue
+		}
+		m := importedMessage{
+			ID:       id,
+			ParentID: keptAncestor(n.Parent),
+			Role:     n.Message.Author.Role,
+			Content:  chatGPTText(n),
+			Model:    n.Message.Metadata.ModelSlug,
+		}
+		if n.Message.CreateTime != nil {
+			m.CreatedAt = unixSeconds(*n.Message.CreateTime)
+		}
+		if m.Model != "" && ic.Model == "" {
+			ic.Model = m.Model
+		}
+		ic.Messages = append(ic.Messages, m)
+	}
+	ic.CurrentID = keptAncestor(export.CurrentNode)
+	fillImportTimes(ic)
+	return ic, nil
+}
+
+func chatGPTText(n chatGPTNode) string {
+	if n.Message.Content.Text != "" {
+		return n.Message.Content.Text
+	}
+	var parts []string
+	for _, raw := range n.Message.Content.Parts {
+		var s string
+		if json.Unmarshal(raw, &s) == nil && s != "" {
+			parts = append(parts, s)
+		}
+	}
+	return strings.TrimSpace(strings.Join(parts, "\n"))
+}
+
+func parseOpenWebUI(doc json.RawMessage) (*importedConversation, error) {
+	type webUIMessage struct {
+		ID        string  `json:"id"`
+		ParentID  *string `json:"parentId"`
+		Role      string  `json:"role"`
+		Content   string  `json:"content"`
+		Model     string  `json:"model"`
+		Timestamp float64 `json:"timestamp"`
+		Info      struct {
+			EvalCount     int   `json:"eval_count"`
+			TotalDuration int64 `json:"total_duration"`
+		} `json:"info"`
+	}
+	var export struct {
+		ID        string  `json:"id"`
+		Title     string  `json:"title"`
+		CreatedAt float64 `json:"created_at"`
+		UpdatedAt float64 `json:"updated_at"`
+		Chat      struct {
+			ID      string   `json:"id"`
+			Title   string   `json:"title"`
+			Models  []string `json:"models"`
+			History struct {
+				CurrentID string                  `json:"currentId"`
+				Messages  map[string]webUIMessage `json:"messages"`
+			} `json:"history"`
+			Messages  []webUIMessage `json:"messages"`
+			Timestamp float64        `json:"timestamp"`
+		} `json:"chat"`
+	}
+	if err := json.Unmarshal(doc, &export); err != nil {
+		return nil, fmt.Errorf("invalid Open WebUI export: %w", err)
+	}
+
+	chat := export.Chat
+	ic := &importedConversation{
+		Source:    importSourceOpenWebUI,
+		SourceID:  export.ID,
+		Title:     export.Title,
+		CreatedAt: unixSeconds(export.CreatedAt),
+		UpdatedAt: unixSeconds(export.UpdatedAt),
+		CurrentID: chat.History.CurrentID,
+	}
+	if ic.SourceID == "" {
+		ic.SourceID = chat.ID
+	}
+	if ic.Title == "" {
+		ic.Title = chat.Title
+	}
+	if ic.CreatedAt.IsZero() {
+		ic.CreatedAt = unixSeconds(chat.Timestamp)
+	}
+	if len(chat.Models) > 0 {
+		ic.Model = chat.Models[0]
+	}
+
+	messages := chat.History.Messages
+	if len(messages) == 0 {
+		messages = make(map[string]webUIMessage, len(chat.Messages))
+		for i, m := range chat.Messages {
+			if m.ID == "" {
+				m.ID = fmt.Sprintf("message-%d", i)
+			}
+			if m.ParentID == nil && i > 0 {
+				parent := chat.Messages[i-1].ID
+				if parent == "" {
+					parent = fmt.Sprintf("message-%d", i-1)
+				}
+				m.ParentID = &parent
+			}
+			messages[m.ID] = m
+		}
+	}
+
+	for id, m := range messages {
+		if !validImportRole(m.Role) {
+			continue
+		}
+		im := importedMessage{
+			ID:        id,
+			Role:      m.Role,
+			Content:   m.Content,
+			Model:     m.Model,
+			Tokens:    m.Info.EvalCount,
+			Duration:  float64(m.Info.TotalDuration) / 1e9,
+			CreatedAt: unixSeconds(m.Timestamp),
+		}
+		if m.ParentID != nil {
+			im.ParentID = *m.ParentID
+		}
+		ic.Messages = append(ic.Messages, im)
+	}
+	fillImportTimes(ic)
+	return ic, nil
+}
+
+func validImportRole(role string) bool {
+	return role == "user" || role == "assistant" || role == "system"
+}
+
+func unixSeconds(v float64) time.Time {
+	if v <= 0 {
+		return time.Time{}
+	}
+	if v > 1e12 {
+		v /= 1000
+	}
+	sec := int64(v)
+	return time.Unix(sec, int64((v-float64(sec))*1e9))
+}
+
+func fillImportTimes(ic *importedConversation) {
+	if ic.CreatedAt.IsZero() {
+		ic.CreatedAt = time.Now()
+	}
+	if ic.UpdatedAt.IsZero() {
+		ic.UpdatedAt = ic.CreatedAt
+	}
+	for i := range ic.Messages {
+		if ic.Messages[i].CreatedAt.IsZero() {
+			ic.Messages[i].CreatedAt = ic.CreatedAt
+		}
+	}
+}
+
+func importHash(doc json.RawMessage) string {
+	sum := sha256.Sum256(doc)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,154 @@
+package api
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const chatGPTExport = `[{
+	"id": "gpt-1",
+	"title": "Trip ideas",
+	"create_time": 1700000000.5,
+	"update_time": 1700000100,
+	"current_node": "a2",
+	"mapping": {
+		"root": {"id": "root", "parent": null, "message": null},
+		"sys": {"id": "sys", "parent": "root", "message": {"author": {"role": "system"}, "content": {"content_type": "text", "parts": [""]}}},
+		"u1": {"id": "u1", "parent": "sys", "message": {"author": {"role": "user"}, "create_time": 1700000001, "content": {"content_type": "text", "parts": ["Where should I go?"]}}},
+		"a1": {"id": "a1", "parent": "u1", "message": {"author": {"role": "assistant"}, "create_time": 1700000002, "content": {"content_type": "text", "parts": ["Lisbon."]}, "metadata": {"model_slug": "gpt-4o"}}},
+		"a2": {"id": "a2", "parent": "u1", "message": {"author": {"role": "assistant"}, "create_time": 1700000003, "content": {"content_type": "text", "parts": ["Kyoto."]}, "metadata": {"model_slug": "gpt-4o"}}}
+	}
+}, {"title": "broken"}]`
+
+const openWebUIExport = `[{
+	"id": "owui-1",
+	"title": "Recipes",
+	"created_at": 1700000000,
+	"updated_at": 1700000050,
+	"chat": {
+		"models": ["llama3"],
+		"history": {
+			"currentId": "m2",
+			"messages": {
+				"m1": {"id": "m1", "parentId": null, "role": "user", "content": "Pasta?", "timestamp": 1700000001},
+				"m2": {"id": "m2", "parentId": "m1", "role": "assistant", "content": "Carbonara.", "model": "llama3", "timestamp": 1700000002, "info": {"eval_count": 12}}
+			}
+		}
+	}
+}]`
+
+func TestImportConversations(t *testing.T) {
+	router, database := newTestRouter(t)
+
+	post := func(body string) map[string]interface{} {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodPost, "/api/import", strings.NewReader(body))
+		req.Header.Set("X-API-Key", "s3cret")
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("import = %d: %s", rec.Code, rec.Body)
+		}
+		var resp map[string]interface{}
+		json.Unmarshal(rec.Body.Bytes(), &resp)
+		return resp["summary"].(map[string]interface{})
+	}
+
+	if s := post(chatGPTExport); s["imported"] != 1.0 || s["failed"] != 1.0 {
+		t.Fatalf("chatgpt summary = %v", s)
+	}
+	if s := post(chatGPTExport); s["imported"] != 0.0 || s["skipped"] != 1.0 {
+		t.Fatalf("re-import summary = %v", s)
+	}
+	if s := post(openWebUIExport); s["imported"] != 1.0 {
+		t.Fatalf("open webui summary = %v", s)
+	}
+
+	convos, err := database.ListConversations("admin-id")
+	if err != nil || len(convos) != 2 {
+		t.Fatalf("conversations = %v, %v", convos, err)
+	}
+	for _, c := range convos {
+		all, _ := database.GetMessages(c.ID, "admin-id")
+		branch, _ := database.GetActiveBranch(c.ID, "admin-id")
+		switch c.Title {
+		case "Trip ideas":
+			if len(all) != 3 || len(branch) != 2 || branch[1].Content != "Kyoto." || branch[1].Model != "gpt-4o" {
+				t.Errorf("chatgpt import: all=%d branch=%+v", len(all), branch)
+			}
+		case "Recipes":
+			if len(branch) != 2 || branch[1].TokensUsed != 12 || c.Model != "llama3" {
+				t.Errorf("open webui import: %+v %+v", c, branch)
+			}
+		default:
+			t.Errorf("unexpected conversation %q", c.Title)
+		}
+	}
+
+	rec := doRequest(t, router, http.MethodGet, "/api/conversations/"+convos[0].ID+"/export?format=json", map[string]string{"X-API-Key": "s3cret"}, nil)
+	if s := post(rec.Body.String()); s["skipped"] != 1.0 {
+		t.Fatalf("importing own export into the same account = %v", s)
+	}
+
+	router2, database2 := newTestRouter(t)
+	req := httptest.NewRequest(http.MethodPost, "/api/import", strings.NewReader(rec.Body.String()))
+	req.Header.Set("X-API-Key", "s3cret")
+	rec2 := httptest.NewRecorder()
+	router2.ServeHTTP(rec2, req)
+	if rec2.Code != http.StatusOK {
+		t.Fatalf("round trip import = %d: %s", rec2.Code, rec2.Body)
+	}
+	imported, _ := database2.ListConversations("admin-id")
+	if len(imported) != 1 || imported[0].Title != convos[0].Title {
+		t.Fatalf("round trip conversations = %+v", imported)
+	}
+}
+
+func TestImportZipSizeLimit(t *testing.T) {
+	router, database := newTestRouter(t)
+	defer func(limit int64) { maxImportExpandedSize = limit }(maxImportExpandedSize)
+	maxImportExpandedSize = 1 << 20
+
+	archive := func(sizes ...int) []byte {
+		var buf bytes.Buffer
+		zw := zip.NewWriter(&buf)
+		for i, size := range sizes {
+			f, _ := zw.Create(fmt.Sprintf("export-%d.json", i))
+			fmt.Fprintf(f, `{"title": "padded", "pad": "%s"}`, strings.Repeat(" ", size))
+		}
+		zw.Close()
+		return buf.Bytes()
+	}
+	post := func(data []byte) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/import", bytes.NewReader(data))
+		req.Header.Set("X-API-Key", "s3cret")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	for name, data := range map[string][]byte{
+		"single entry": archive(2 << 20),
+		"total":        archive(600<<10, 600<<10),
+	} {
+		if len(data) > 64<<10 {
+			t.Fatalf("%s: archive is %d bytes, want a small compressed upload", name, len(data))
+		}
+		if rec := post(data); rec.Code != http.StatusRequestEntityTooLarge {
+			t.Errorf("%s = %d: %s, want 413", name, rec.Code, rec.Body)
+		}
+	}
+	if convos, _ := database.ListConversations("admin-id"); len(convos) != 0 {
+		t.Fatalf("oversized import stored %d conversations", len(convos))
+	}
+	if rec := post(archive(100 << 10)); rec.Code != http.StatusOK {
+		t.Fatalf("import within the limit = %d: %s", rec.Code, rec.Body)
+	}
+}
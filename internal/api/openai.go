@@ -0,0 +1,357 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ifauzeee/Zee-AI/internal/db"
+	"github.com/ifauzeee/Zee-AI/internal/ollama"
+)
+
+type openAIContent string
+
+func (c *openAIContent) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*c = openAIContent(s)
+		return nil
+	}
+	var parts []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	}
+	if err := json.Unmarshal(data, &parts); err != nil {
+		return errors.New("content must be a string or an array of content parts")
+	}
+	var texts []string
+	for _, p := range parts {
+		if p.Type == "text" {
+			texts = append(texts, p.Text)
+		}
+	}
+	*c = openAIContent(strings.Join(texts, "\n"))
+	return nil
+}
+
+type openAIStop []string
+
+func (s *openAIStop) UnmarshalJSON(data []byte) error {
+	var one string
+	if err := json.Unmarshal(data, &one); err == nil {
+		*s = openAIStop{one}
+		return nil
+	}
+	var many []string
+	if err := json.Unmarshal(data, &many); err != nil {
+		return errors.New("stop must be a string or an array of strings")
+	}
+	*s = many
+	return nil
+}
+
+type openAIMessage struct {
+	Role    string        `json:"role"`
+	Content openAIContent `json:"content"`
+}
+
+type openAIChatRequest struct {
+	Model               string          `json:"model"`
+	Messages            []openAIMessage `json:"messages"`
+	Stream              bool            `json:"stream"`
+	Temperature         *float64        `json:"temperature"`
+	TopP                *float64        `json:"top_p"`
+	MaxTokens           *int            `json:"max_tokens"`
+	MaxCompletionTokens *int            `json:"max_completion_tokens"`
+	Seed                *int            `json:"seed"`
+	PresencePenalty     *float64        `json:"presence_penalty"`
+	FrequencyPenalty    *float64        `json:"frequency_penalty"`
+	Stop                openAIStop      `json:"stop"`
+	N                   *int            `json:"n"`
+	Store               *bool           `json:"store"`
+	StreamOptions       *struct {
+		IncludeUsage bool `json:"include_usage"`
+	} `json:"stream_options"`
+}
+
+type openAIUsage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
+type openAIChoice struct {
+	Index        int          `json:"index"`
+	Message      *openAIReply `json:"message,omitempty"`
+	Delta        *openAIReply `json:"delta,omitempty"`
+	FinishReason *string      `json:"finish_reason"`
+}
+
+type openAIReply struct {
+	Role    string `json:"role,omitempty"`
+	Content string `json:"content"`
+}
+
+type openAICompletion struct {
+	ID      string         `json:"id"`
+	Object  string         `json:"object"`
+	Created int64          `json:"created"`
+	Model   string         `json:"model"`
+	Choices []openAIChoice `json:"choices"`
+	Usage   *openAIUsage   `json:"usage,omitempty"`
+}
+
+func openAIError(errType, message string) map[string]interface{} {
+	return map[string]interface{}{
+		"error": map[string]interface{}{
+			"message": message,
+			"type":    errType,
+			"code":    nil,
+		},
+	}
+}
+
+func writeOpenAIError(w http.ResponseWriter, status int, errType, message string) {
+	writeJSON(w, status, openAIError(errType, message))
+}
+
+func (h *Handler) OpenAIListModels(w http.ResponseWriter, r *http.Request) {
+	models, err := h.llm.ListModels(r.Context())
+	if err != nil {
+		writeOpenAIError(w, http.StatusBadGateway, "api_error", "Failed to list models: "+err.Error())
+		return
+	}
+
+	data := make([]map[string]interface{}, 0, len(models))
+	for _, m := range models {
+		data = append(data, map[string]interface{}{
+			"id":       m.Name,
+			"object":   "model",
+			"created":  m.ModifiedAt.Unix(),
+			"owned_by": "zee-ai",
+		})
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"object": "list",
+		"data":   data,
+	})
+}
+
+func (h *Handler) OpenAIChatCompletions(w http.ResponseWriter, r *http.Request) {
+	var req openAIChatRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeOpenAIError(w, http.StatusBadRequest, "invalid_request_error", "Invalid request body: "+err.Error())
+		return
+	}
+	if req.Model == "" {
+		writeOpenAIError(w, http.StatusBadRequest, "invalid_request_error", "model is required")
+		return
+	}
+	if len(req.Messages) == 0 {
+		writeOpenAIError(w, http.StatusBadRequest, "invalid_request_error", "messages must not be empty")
+		return
+	}
+	if req.N != nil && *req.N != 1 {
+		writeOpenAIError(w, http.StatusBadRequest, "invalid_request_error", "only n=1 is supported")
+		return
+	}
+
+	options := openAIOptions(&req)
+	if err := validateOptions(options); err != nil {
+		writeOpenAIError(w, http.StatusBadRequest, "invalid_request_error", err.Error())
+		return
+	}
+
+	messages := make([]ollama.ChatMessage, 0, len(req.Messages))
+	for _, m := range req.Messages {
+		role := m.Role
+		if role == "developer" {
+			role = "system"
+		}
+		switch role {
+		case "system", "user", "assistant":
+		default:
+			writeOpenAIError(w, http.StatusBadRequest, "invalid_request_error", fmt.Sprintf("unsupported message role %q", m.Role))
+			return
+		}
+		messages = append(messages, ollama.ChatMessage{Role: role, Content: string(m.Content)})
+	}
+
+	chatReq := &ollama.ChatRequest{
+		Model:    req.Model,
+		Messages: messages,
+		Options:  options,
+	}
+	completion := openAICompletion{
+		ID:      "chatcmpl-" + strings.ReplaceAll(uuid.New().String(), "-", ""),
+		Created: time.Now().Unix(),
+		Model:   req.Model,
+	}
+	store := h.cfg.OpenAILogConversations
+	if req.Store != nil {
+		store = *req.Store
+	}
+
+	start := time.Now()
+	var reply ollama.ChatResponse
+	if req.Stream {
+		reply = h.streamOpenAICompletion(w, r, chatReq, completion, req.StreamOptions != nil && req.StreamOptions.IncludeUsage)
+		if reply.Done && store {
+			h.logCompletion(currentUser(r).ID, req.Model, messages, reply, time.Since(start))
+		}
+		return
+	}
+
+	resp, err := h.llm.Chat(r.Context(), chatReq)
+	if err != nil {
+		writeOpenAIError(w, http.StatusBadGateway, "api_error", err.Error())
+		return
+	}
+	finish := openAIFinishReason(resp.DoneReason)
+	completion.Object = "chat.completion"
+	completion.Choices = []openAIChoice{{
+		Message:      &openAIReply{Role: "assistant", Content: resp.Message.Content},
+		FinishReason: &finish,
+	}}
+	completion.Usage = &openAIUsage{
+		PromptTokens:     resp.PromptEvalCount,
+		CompletionTokens: resp.EvalCount,
+		TotalTokens:      resp.PromptEvalCount + resp.EvalCount,
+	}
+	if store {
+		h.logCompletion(currentUser(r).ID, req.Model, messages, *resp, time.Since(start))
+	}
+	writeJSON(w, http.StatusOK, completion)
+}
+
+func (h *Handler) streamOpenAICompletion(w http.ResponseWriter, r *http.Request, chatReq *ollama.ChatRequest, completion openAICompletion, includeUsage bool) ollama.ChatResponse {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeOpenAIError(w, http.StatusInternalServerError, "api_error", "Streaming not supported")
+		return ollama.ChatResponse{}
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+
+	completion.Object = "chat.completion.chunk"
+	send := func(choices []openAIChoice, usage *openAIUsage) {
+		completion.Choices = choices
+		completion.Usage = usage
+		data, _ := json.Marshal(completion)
+		fmt.Fprintf(w, "data: %s\n\n", data)
+		flusher.Flush()
+	}
+
+	send([]openAIChoice{{Delta: &openAIReply{Role: "assistant"}}}, nil)
+
+	var full strings.Builder
+	var final ollama.ChatResponse
+	err := h.llm.ChatStream(r.Context(), chatReq, func(chunk ollama.ChatResponse) error {
+		if chunk.Message.Content != "" {
+			full.WriteString(chunk.Message.Content)
+			send([]openAIChoice{{Delta: &openAIReply{Content: chunk.Message.Content}}}, nil)
+		}
+		if chunk.Done {
+			final = chunk
+		}
+		return nil
+	})
+	if err != nil {
+		if r.Context().Err() == nil {
+			data, _ := json.Marshal(openAIError("api_error", err.Error()))
+			fmt.Fprintf(w, "data: %s\n\n", data)
+			fmt.Fprint(w, "data: [DONE]\n\n")
+			flusher.Flush()
+		}
+		return ollama.ChatResponse{}
+	}
+
+	finish := openAIFinishReason(final.DoneReason)
+	send([]openAIChoice{{Delta: &openAIReply{}, FinishReason: &finish}}, nil)
+	if includeUsage {
+		send([]openAIChoice{}, &openAIUsage{
+			PromptTokens:     final.PromptEvalCount,
+			CompletionTokens: final.EvalCount,
+			TotalTokens:      final.PromptEvalCount + final.EvalCount,
+		})
+	}
+	fmt.Fprint(w, "data: [DONE]\n\n")
+	flusher.Flush()
+
+	final.Message = ollama.ChatMessage{Role: "assistant", Content: full.String()}
+	return final
+}
+
+func openAIOptions(req *openAIChatRequest) *ollama.Options {
+	opts := &ollama.Options{
+		Temperature:      req.Temperature,
+		TopP:             req.TopP,
+		NumPredict:       req.MaxTokens,
+		Seed:             req.Seed,
+		PresencePenalty:  req.PresencePenalty,
+		FrequencyPenalty: req.FrequencyPenalty,
+		Stop:             req.Stop,
+	}
+	if req.MaxCompletionTokens != nil {
+		opts.NumPredict = req.MaxCompletionTokens
+	}
+	return opts
+}
+
+func openAIFinishReason(doneReason string) string {
+	if doneReason == "length" {
+		return "length"
+	}
+	return "stop"
+}
+
+func (h *Handler) logCompletion(userID, model string, messages []ollama.ChatMessage, reply ollama.ChatResponse, elapsed time.Duration) {
+	title := "API Chat"
+	for _, m := range messages {
+		if m.Role == "user" && strings.TrimSpace(m.Content) != "" {
+			title = strings.Join(strings.Fields(m.Content), " ")
+			if r := []rune(title); len(r) > 50 {
+				title = string(r[:50]) + "…"
+			}
+			break
+		}
+	}
+
+	convo, err := h.db.CreateConversation(uuid.New().String(), userID, title, model)
+	if err != nil {
+		h.logger.Error("log completion failed", "error", err)
+		return
+	}
+	parentID := ""
+	save := func(msg *db.Message) bool {
+		msg.ID = uuid.New().String()
+		msg.ConversationID = convo.ID
+		msg.ParentID = parentID
+		msg.CreatedAt = time.Now()
+		if err := h.db.CreateMessage(msg); err != nil {
+			h.logger.Error("log completion failed", "error", err)
+			return false
+		}
+		parentID = msg.ID
+		return true
+	}
+	for _, m := range messages {
+		if !save(&db.Message{Role: m.Role, Content: m.Content}) {
+			return
+		}
+	}
+	save(&db.Message{
+		Role:       "assistant",
+		Content:    reply.Message.Content,
+		Model:      model,
+		TokensUsed: reply.EvalCount + reply.PromptEvalCount,
+		Duration:   elapsed.Seconds(),
+	})
+}
@@ -0,0 +1,215 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ifauzeee/Zee-AI/internal/ollama"
+)
+
+type fakeOllama struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	requests []ollama.ChatRequest
+}
+
+func newFakeOllama(t *testing.T, reply string) *fakeOllama {
+	t.Helper()
+
+	f := &fakeOllama{}
+	f.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/tags":
+			fmt.Fprint(w, `{"models":[{"name":"llama3:latest","modified_at":"2024-01-01T00:00:00Z"}]}`)
+		case "/api/chat":
+			var req ollama.ChatRequest
+			json.NewDecoder(r.Body).Decode(&req)
+			f.mu.Lock()
+			f.requests = append(f.requests, req)
+			f.mu.Unlock()
+			if !req.Stream {
+				fmt.Fprintf(w, `{"message":{"role":"assistant","content":%q},"done":true,"done_reason":"stop","prompt_eval_count":5,"eval_count":3}`, reply)
+				return
+			}
+			for _, word := range strings.SplitAfter(reply, " ") {
+				fmt.Fprintf(w, `{"message":{"role":"assistant","content":%q},"done":false}`+"\n", word)
+			}
+			fmt.Fprint(w, `{"message":{"role":"assistant","content":""},"done":true,"done_reason":"length","prompt_eval_count":5,"eval_count":3}`+"\n")
+		case "/api/delete":
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(f.Close)
+	return f
+}
+
+func (f *fakeOllama) chats(stream bool) []ollama.ChatRequest {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var out []ollama.ChatRequest
+	for _, req := range f.requests {
+		if req.Stream == stream {
+			out = append(out, req)
+		}
+	}
+	return out
+}
+
+func (f *fakeOllama) lastChat(t *testing.T, stream bool) ollama.ChatRequest {
+	t.Helper()
+	chats := f.chats(stream)
+	if len(chats) == 0 {
+		t.Fatalf("no chat requests with stream=%v", stream)
+	}
+	return chats[len(chats)-1]
+}
+
+func (f *fakeOllama) waitForChats(t *testing.T, stream bool, n int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for len(f.chats(stream)) < n {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %d chat requests with stream=%v", n, stream)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestOpenAIChatCompletions(t *testing.T) {
+	fake := newFakeOllama(t, "Hello there friend")
+	router, database := newTestRouterWithOllama(t, fake.URL)
+	headers := map[string]string{"Authorization": "Bearer s3cret"}
+
+	rec := doRequest(t, router, http.MethodGet, "/v1/models", headers, nil)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"id":"llama3:latest"`) {
+		t.Fatalf("models = %d: %s", rec.Code, rec.Body)
+	}
+
+	rec = doRequest(t, router, http.MethodPost, "/v1/chat/completions", headers, map[string]interface{}{
+		"model":       "llama3",
+		"messages":    []map[string]interface{}{{"role": "user", "content": []map[string]string{{"type": "text", "text": "hi"}}}},
+		"temperature": 0.5,
+		"max_tokens":  64,
+		"seed":        7,
+		"stop":        "END",
+		"store":       true,
+	})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("completion = %d: %s", rec.Code, rec.Body)
+	}
+	var completion openAICompletion
+	json.Unmarshal(rec.Body.Bytes(), &completion)
+	if completion.Object != "chat.completion" || completion.Choices[0].Message.Content != "Hello there friend" || completion.Usage.TotalTokens != 8 {
+		t.Fatalf("completion = %s", rec.Body)
+	}
+	last := fake.lastChat(t, false)
+	if o := last.Options; o.Temperature == nil || *o.Temperature != 0.5 || *o.NumPredict != 64 || *o.Seed != 7 || len(o.Stop) != 1 || o.Stop[0] != "END" {
+		t.Fatalf("options = %+v", o)
+	}
+	if last.Messages[0].Content != "hi" {
+		t.Fatalf("messages = %+v", last.Messages)
+	}
+	convos, _ := database.ListConversations("admin-id")
+	if len(convos) != 1 || convos[0].Title != "hi" {
+		t.Fatalf("stored conversations = %+v", convos)
+	}
+	if replies := assistantMessages(t, database, convos[0].ID); len(replies) != 1 || replies[0].TokensUsed != 8 {
+		t.Fatalf("stored replies = %+v, want prompt and completion tokens counted", replies)
+	}
+
+	rec = doRequest(t, router, http.MethodPost, "/v1/chat/completions", headers, map[string]interface{}{
+		"model":          "llama3",
+		"messages":       []map[string]string{{"role": "user", "content": "hi"}},
+		"stream":         true,
+		"stream_options": map[string]bool{"include_usage": true},
+	})
+	body := rec.Body.String()
+	if rec.Header().Get("Content-Type") != "text/event-stream" || !strings.HasSuffix(body, "data: [DONE]\n\n") {
+		t.Fatalf("stream = %q", body)
+	}
+	var content strings.Builder
+	var finish string
+	for _, line := range strings.Split(body, "\n") {
+		data, ok := strings.CutPrefix(line, "data: ")
+		if !ok || data == "[DONE]" {
+			continue
+		}
+		var chunk openAICompletion
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			t.Fatalf("decode chunk %q: %v", data, err)
+		}
+		for _, c := range chunk.Choices {
+			content.WriteString(c.Delta.Content)
+			if c.FinishReason != nil {
+				finish = *c.FinishReason
+			}
+		}
+	}
+	if content.String() != "Hello there friend" || finish != "length" {
+		t.Fatalf("streamed %q, finish %q", content.String(), finish)
+	}
+	if convos, _ := database.ListConversations("admin-id"); len(convos) != 1 {
+		t.Fatalf("unstored stream created a conversation")
+	}
+
+	var authErr struct {
+		Error struct {
+			Message string `json:"message"`
+			Type    string `json:"type"`
+		} `json:"error"`
+	}
+	rec = doRequest(t, router, http.MethodPost, "/v1/chat/completions", nil, map[string]string{"model": "llama3"})
+	json.Unmarshal(rec.Body.Bytes(), &authErr)
+	if rec.Code != http.StatusUnauthorized || authErr.Error.Message != "Missing credentials" || authErr.Error.Type == "" {
+		t.Fatalf("unauthenticated = %d: %s", rec.Code, rec.Body)
+	}
+}
+
+type failingStreamLLM struct {
+	*blockingLLM
+}
+
+func (l failingStreamLLM) ChatStream(ctx context.Context, req *ollama.ChatRequest, onChunk func(ollama.ChatResponse) error) error {
+	onChunk(ollama.ChatResponse{Message: ollama.ChatMessage{Role: "assistant", Content: "partial"}})
+	return errors.New("backend went away")
+}
+
+func TestOpenAIStreamError(t *testing.T) {
+	router, _ := newTestRouterWithProvider(t, failingStreamLLM{newBlockingLLM(false)})
+	rec := doRequest(t, router, http.MethodPost, "/v1/chat/completions", map[string]string{"Authorization": "Bearer s3cret"}, map[string]interface{}{
+		"model":    "llama3",
+		"messages": []map[string]string{{"role": "user", "content": "hi"}},
+		"stream":   true,
+	})
+	body := rec.Body.String()
+	if !strings.HasSuffix(body, "data: [DONE]\n\n") {
+		t.Fatalf("stream = %q, want it terminated with [DONE]", body)
+	}
+
+	var events []string
+	for _, line := range strings.Split(body, "\n") {
+		if data, ok := strings.CutPrefix(line, "data: "); ok {
+			events = append(events, data)
+		}
+	}
+	var failure struct {
+		Error struct {
+			Message string `json:"message"`
+			Type    string `json:"type"`
+		} `json:"error"`
+	}
+	if len(events) < 2 || json.Unmarshal([]byte(events[len(events)-2]), &failure) != nil ||
+		failure.Error.Message != "backend went away" || failure.Error.Type != "api_error" {
+		t.Fatalf("stream = %q, want an OpenAI error object before [DONE]", body)
+	}
+}
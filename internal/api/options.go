@@ -0,0 +1,162 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ifauzeee/Zee-AI/internal/ollama"
+)
+
+const maxStopSequences = 16
+
+func parseOptions(raw json.RawMessage) (*ollama.Options, error) {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 || bytes.Equal(raw, []byte("null")) {
+		return nil, nil
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return nil, errors.New("options must be an object")
+	}
+	if _, ok := fields["keep_alive"]; ok {
+		return nil, errors.New("keep_alive is a request field, not an option")
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.DisallowUnknownFields()
+	var opts ollama.Options
+	if err := dec.Decode(&opts); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) {
+			return nil, fmt.Errorf("option %s must be %s", typeErr.Field, jsonTypeName(typeErr.Type.String()))
+		}
+		if name, ok := strings.CutPrefix(err.Error(), "json: unknown field "); ok {
+			return nil, fmt.Errorf("unknown option %s", name)
+		}
+		return nil, err
+	}
+	if err := validateOptions(&opts); err != nil {
+		return nil, err
+	}
+	return &opts, nil
+}
+
+func jsonTypeName(goType string) string {
+	switch strings.TrimPrefix(goType, "*") {
+	case "int":
+		return "an integer"
+	case "float64":
+		return "a number"
+	case "bool":
+		return "a boolean"
+	case "[]string", "string":
+		return "a list of strings"
+	}
+	return goType
+}
+
+func validateOptions(o *ollama.Options) error {
+	checks := []error{
+		floatRange("temperature", o.Temperature, 0, 2),
+		floatRange("top_p", o.TopP, 0, 1),
+		floatRange("min_p", o.MinP, 0, 1),
+		floatRange("typical_p", o.TypicalP, 0, 1),
+		floatRange("repeat_penalty", o.RepeatPenalty, 0, math.Inf(1)),
+		floatRange("presence_penalty", o.PresencePenalty, -2, 2),
+		floatRange("frequency_penalty", o.FrequencyPenalty, -2, 2),
+		floatRange("mirostat_tau", o.MirostatTau, 0, math.Inf(1)),
+		floatRange("mirostat_eta", o.MirostatEta, 0, math.Inf(1)),
+		intRange("top_k", o.TopK, 0, math.MaxInt),
+		intRange("num_predict", o.NumPredict, -2, math.MaxInt),
+		intRange("num_keep", o.NumKeep, -1, math.MaxInt),
+		intRange("repeat_last_n", o.RepeatLastN, -1, math.MaxInt),
+		intRange("mirostat", o.Mirostat, 0, 2),
+		intRange("num_ctx", o.NumCtx, 1, math.MaxInt),
+		intRange("num_batch", o.NumBatch, 1, math.MaxInt),
+		intRange("num_gpu", o.NumGPU, -1, math.MaxInt),
+		intRange("main_gpu", o.MainGPU, 0, math.MaxInt),
+		intRange("num_thread", o.NumThread, 0, math.MaxInt),
+	}
+	for _, err := range checks {
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(o.Stop) > maxStopSequences {
+		return fmt.Errorf("option stop accepts at most %d sequences", maxStopSequences)
+	}
+	for _, s := range o.Stop {
+		if s == "" {
+			return errors.New("option stop must not contain empty sequences")
+		}
+	}
+	return nil
+}
+
+func floatRange(name string, v *float64, min, max float64) error {
+	if v == nil {
+		return nil
+	}
+	if math.IsNaN(*v) || *v < min || *v > max {
+		if math.IsInf(max, 1) {
+			return fmt.Errorf("option %s must be at least %v", name, min)
+		}
+		return fmt.Errorf("option %s must be between %v and %v", name, min, max)
+	}
+	return nil
+}
+
+func intRange(name string, v *int, min, max int) error {
+	if v == nil {
+		return nil
+	}
+	if *v < min || *v > max {
+		if max == math.MaxInt {
+			return fmt.Errorf("option %s must be at least %d", name, min)
+		}
+		return fmt.Errorf("option %s must be between %d and %d", name, min, max)
+	}
+	return nil
+}
+
+func parseKeepAlive(raw json.RawMessage) (json.RawMessage, error) {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 || bytes.Equal(raw, []byte("null")) {
+		return nil, nil
+	}
+	var v interface{}
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return nil, errors.New("keep_alive must be a duration or a number of seconds")
+	}
+	switch val := v.(type) {
+	case float64:
+		return raw, nil
+	case string:
+		if _, err := time.ParseDuration(val); err == nil {
+			return raw, nil
+		}
+	}
+	return nil, errors.New(`keep_alive must be a duration such as "10m" or a number of seconds (negative keeps the model loaded)`)
+}
+
+func (h *Handler) generationOptions(w http.ResponseWriter, rawOptions, rawKeepAlive json.RawMessage) (*ollama.Options, json.RawMessage, bool) {
+	opts, err := parseOptions(rawOptions)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid options: "+err.Error())
+		return nil, nil, false
+	}
+	keepAlive, err := parseKeepAlive(rawKeepAlive)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid options: "+err.Error())
+		return nil, nil, false
+	}
+	return opts, keepAlive, true
+}
@@ -0,0 +1,80 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestChatOptions(t *testing.T) {
+	fake := newFakeOllama(t, "ok")
+	router, _ := newTestRouterWithOllama(t, fake.URL)
+	headers := map[string]string{"Authorization": "Bearer s3cret"}
+
+	for body, want := range map[string]string{
+		`{"temperature": 3}`:                "option temperature must be between 0 and 2",
+		`{"top_k": -1}`:                     "option top_k must be at least 0",
+		`{"mirostat": 3}`:                   "option mirostat must be between 0 and 2",
+		`{"temprature": 0.1}`:               `unknown option \"temprature\"`,
+		`{"num_ctx": "big"}`:                "option num_ctx must be an integer",
+		`{"stop": [""]}`:                    "option stop must not contain empty sequences",
+		`{"keep_alive": "5m"}`:              "keep_alive is a request field",
+		`["temperature"]`:                   "options must be an object",
+		`{"presence_penalty": -2.5}`:        "option presence_penalty must be between -2 and 2",
+		`{"repeat_penalty": -1, "seed": 1}`: "option repeat_penalty must be at least 0",
+	} {
+		rec := doRequest(t, router, http.MethodPost, "/api/chat", headers, map[string]interface{}{
+			"model":   "llama3",
+			"message": "hi",
+			"options": json.RawMessage(body),
+		})
+		if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), want) {
+			t.Errorf("options %s = %d %s, want %q", body, rec.Code, rec.Body, want)
+		}
+	}
+	rec := doRequest(t, router, http.MethodPost, "/api/chat", headers, map[string]interface{}{
+		"model":      "llama3",
+		"message":    "hi",
+		"keep_alive": "soon",
+	})
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("keep_alive = %d, want 400", rec.Code)
+	}
+
+	rec = doRequest(t, router, http.MethodPost, "/api/chat", headers, map[string]interface{}{
+		"model":      "llama3",
+		"message":    "hi",
+		"keep_alive": "10m",
+		"options": map[string]interface{}{
+			"temperature":       0,
+			"seed":              0,
+			"num_ctx":           8192,
+			"repeat_penalty":    1.1,
+			"repeat_last_n":     -1,
+			"frequency_penalty": 0.5,
+			"mirostat":          2,
+			"mirostat_tau":      5,
+			"min_p":             0.05,
+			"penalize_newline":  false,
+			"stop":              []string{"</s>"},
+		},
+	})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("chat = %d: %s", rec.Code, rec.Body)
+	}
+	fake.waitForChats(t, false, 1)
+	streamed := fake.chats(true)
+	if len(streamed) != 1 {
+		t.Fatalf("streamed chat requests = %d, want 1", len(streamed))
+	}
+	last := streamed[0]
+	o := last.Options
+	if o == nil || o.Temperature == nil || *o.Temperature != 0 || o.Seed == nil || *o.Seed != 0 ||
+		o.NumCtx == nil || *o.NumCtx != 8192 || *o.RepeatLastN != -1 || *o.Mirostat != 2 || *o.PenalizeNewline || o.Stop[0] != "</s>" {
+		t.Fatalf("ollama options = %+v", o)
+	}
+	if string(last.KeepAlive) != `"10m"` {
+		t.Fatalf("keep_alive = %s", last.KeepAlive)
+	}
+}
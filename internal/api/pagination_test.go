@@ -0,0 +1,166 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/ifauzeee/Zee-AI/internal/db"
+)
+
+func TestListConversationsPagination(t *testing.T) {
+	router, database := newTestRouter(t)
+	headers := map[string]string{"X-API-Key": "s3cret"}
+
+	for i := 0; i < 5; i++ {
+		model := "llama"
+		if i%2 == 1 {
+			model = "gemma"
+		}
+		if _, err := database.CreateConversation(fmt.Sprintf("c%d", i), "admin-id", "Chat", model); err != nil {
+			t.Fatalf("create conversation: %v", err)
+		}
+	}
+
+	type page struct {
+		Conversations []db.Conversation `json:"conversations"`
+		NextCursor    string            `json:"next_cursor"`
+	}
+	fetch := func(path string) page {
+		t.Helper()
+		rec := doRequest(t, router, http.MethodGet, path, headers, nil)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("GET %s = %d: %s", path, rec.Code, rec.Body)
+		}
+		var p page
+		if err := json.Unmarshal(rec.Body.Bytes(), &p); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		return p
+	}
+
+	var ids []string
+	path := "/api/conversations?limit=2&sort=created_asc"
+	for {
+		p := fetch(path)
+		for _, c := range p.Conversations {
+			ids = append(ids, c.ID)
+		}
+		if p.NextCursor == "" {
+			break
+		}
+		path = "/api/conversations?limit=2&sort=created_asc&cursor=" + p.NextCursor
+	}
+	if fmt.Sprint(ids) != "[c0 c1 c2 c3 c4]" {
+		t.Fatalf("paged ids = %v", ids)
+	}
+
+	if p := fetch("/api/conversations?model=gemma"); len(p.Conversations) != 2 || p.NextCursor != "" {
+		t.Fatalf("model filter returned %d conversations, cursor %q", len(p.Conversations), p.NextCursor)
+	}
+	if p := fetch("/api/conversations?from=2000-01-01&to=2000-01-02"); len(p.Conversations) != 0 {
+		t.Fatalf("date filter returned %d conversations", len(p.Conversations))
+	}
+
+	for _, bad := range []string{"limit=0", "sort=sideways", "cursor=!!", "from=yesterday"} {
+		if rec := doRequest(t, router, http.MethodGet, "/api/conversations?"+bad, headers, nil); rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want 400", bad, rec.Code)
+		}
+	}
+}
+
+func TestGetMessagesPagination(t *testing.T) {
+	router, database := newTestRouter(t)
+	headers := map[string]string{"X-API-Key": "s3cret"}
+
+	if _, err := database.CreateConversation("conv", "admin-id", "Chat", "llama"); err != nil {
+		t.Fatalf("create conversation: %v", err)
+	}
+	parent := ""
+	for i := 0; i < 4; i++ {
+		role := "user"
+		if i%2 == 1 {
+			role = "assistant"
+		}
+		msg := &db.Message{ID: fmt.Sprintf("m%d", i), ConversationID: "conv", ParentID: parent, Role: role, Content: "hi"}
+		if err := database.CreateMessage(msg); err != nil {
+			t.Fatalf("create message: %v", err)
+		}
+		parent = msg.ID
+	}
+	sibling := &db.Message{ID: "m3b", ConversationID: "conv", ParentID: "m2", Role: "assistant", Content: "alt"}
+	if err := database.CreateMessage(sibling); err != nil {
+		t.Fatalf("create message: %v", err)
+	}
+
+	fetch := func(path string) ([]string, string) {
+		t.Helper()
+		rec := doRequest(t, router, http.MethodGet, path, headers, nil)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("GET %s = %d: %s", path, rec.Code, rec.Body)
+		}
+		var p struct {
+			Messages   []db.Message `json:"messages"`
+			NextCursor string       `json:"next_cursor"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &p); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		var ids []string
+		for _, m := range p.Messages {
+			ids = append(ids, m.ID)
+		}
+		return ids, p.NextCursor
+	}
+
+	ids, next := fetch("/api/conversations/conv/messages?limit=3")
+	if fmt.Sprint(ids) != "[m0 m1 m2]" || next == "" {
+		t.Fatalf("first page = %v, cursor %q", ids, next)
+	}
+	ids, next = fetch("/api/conversations/conv/messages?limit=3&cursor=" + next)
+	if fmt.Sprint(ids) != "[m3b]" || next != "" {
+		t.Fatalf("second page = %v, cursor %q", ids, next)
+	}
+
+	ids, _ = fetch("/api/conversations/conv/messages?tree=true&sort=desc&role=assistant")
+	if fmt.Sprint(ids) != "[m3b m3 m1]" {
+		t.Fatalf("tree page = %v", ids)
+	}
+}
+
+func TestListConversationsDefaultPage(t *testing.T) {
+	router, database := newTestRouter(t)
+	headers := map[string]string{"X-API-Key": "s3cret"}
+
+	total := db.DefaultPageSize + 10
+	for i := 0; i < total; i++ {
+		if _, err := database.CreateConversation(fmt.Sprintf("c%02d", i), "admin-id", "Chat", "llama"); err != nil {
+			t.Fatalf("create conversation: %v", err)
+		}
+	}
+
+	seen := map[string]bool{}
+	pages := 0
+	for path := "/api/conversations"; path != ""; pages++ {
+		rec := doRequest(t, router, http.MethodGet, path, headers, nil)
+		var p struct {
+			Conversations []db.Conversation `json:"conversations"`
+			NextCursor    string            `json:"next_cursor"`
+		}
+		json.Unmarshal(rec.Body.Bytes(), &p)
+		if rec.Code != http.StatusOK || (pages == 0 && (len(p.Conversations) != db.DefaultPageSize || p.NextCursor == "")) {
+			t.Fatalf("GET %s = %d, %d conversations, cursor %q", path, rec.Code, len(p.Conversations), p.NextCursor)
+		}
+		for _, c := range p.Conversations {
+			seen[c.ID] = true
+		}
+		path = ""
+		if p.NextCursor != "" {
+			path = "/api/conversations?cursor=" + p.NextCursor
+		}
+	}
+	if len(seen) != total || pages != 2 {
+		t.Fatalf("followed %d pages to %d conversations, want 2 pages and %d", pages, len(seen), total)
+	}
+}
@@ -0,0 +1,62 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ifauzeee/Zee-AI/internal/provider"
+)
+
+func TestProviderRoutes(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/models" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, `{"object":"list","data":[{"id":"qwen2.5-7b","created":1700000000}]}`)
+	}))
+	t.Cleanup(backend.Close)
+	fake := newFakeOllama(t, "from ollama")
+	pool := provider.NewPool([]string{fake.URL, "http://127.0.0.1:0"}, provider.BalanceRoundRobin)
+	pool.Refresh(context.Background())
+
+	llm := provider.NewRouter("ollama", pool)
+	llm.Register("lmstudio", provider.NewOpenAI(backend.URL+"/v1", ""))
+	router, _ := newTestRouterWithProvider(t, llm)
+	headers := map[string]string{"Authorization": "Bearer s3cret"}
+
+	var health struct {
+		Status   string                   `json:"status"`
+		Ollama   bool                     `json:"ollama"`
+		Backends []provider.BackendStatus `json:"backends"`
+	}
+	rec := doRequest(t, router, http.MethodGet, "/api/health", nil, nil)
+	json.Unmarshal(rec.Body.Bytes(), &health)
+	if health.Status != "degraded" || !health.Ollama || len(health.Backends) != 2 || health.Backends[1].Healthy {
+		t.Fatalf("health = %s", rec.Body)
+	}
+
+	rec = doRequest(t, router, http.MethodGet, "/api/models", headers, nil)
+	if body := rec.Body.String(); !strings.Contains(body, `"lmstudio/qwen2.5-7b"`) || strings.Count(body, `"name":"llama3:latest"`) != 1 {
+		t.Fatalf("models = %s", body)
+	}
+
+	rec = doRequest(t, router, http.MethodPost, "/api/chat", headers, map[string]string{
+		"model":   "llama3:latest",
+		"message": "hello",
+	})
+	if rec.Code != http.StatusOK || fake.lastChat(t, true).Model != "llama3:latest" {
+		t.Fatalf("ollama chat = %d: %s", rec.Code, rec.Body)
+	}
+
+	for _, path := range []string{"/api/models/lmstudio/qwen2.5-7b", "/api/models/lmstudio%2Fqwen2.5-7b"} {
+		if rec := doRequest(t, router, http.MethodDelete, path, headers, nil); rec.Code != http.StatusBadRequest {
+			t.Fatalf("DELETE %s = %d, want 400", path, rec.Code)
+		}
+	}
+}
@@ -5,26 +5,35 @@ import (
 	"log/slog"
 	"net/http"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/ifauzeee/Zee-AI/internal/config"
 	"github.com/ifauzeee/Zee-AI/internal/db"
-	"github.com/ifauzeee/Zee-AI/internal/ollama"
+	"github.com/ifauzeee/Zee-AI/internal/provider"
+	"github.com/ifauzeee/Zee-AI/internal/tools"
 )
 
 type Handler struct {
-	db     *db.DB
-	ollama *ollama.Client
-	cfg    *config.Config
-	logger *slog.Logger
+	db          *db.DB
+	llm         provider.Provider
+	cfg         *config.Config
+	logger      *slog.Logger
+	generations *generationRegistry
+	limits      *contextLimits
+	tools       *tools.Registry
+	summarizing sync.Map
 }
 
-func NewHandler(database *db.DB, ollamaClient *ollama.Client, cfg *config.Config, logger *slog.Logger) *Handler {
+func NewHandler(database *db.DB, llm provider.Provider, cfg *config.Config, logger *slog.Logger) *Handler {
 	return &Handler{
-		db:     database,
-		ollama: ollamaClient,
-		cfg:    cfg,
-		logger: logger,
+		db:          database,
+		llm:         llm,
+		cfg:         cfg,
+		logger:      logger,
+		generations: newGenerationRegistry(),
+		limits:      &contextLimits{models: make(map[string]int)},
+		tools:       tools.Builtin(database),
 	}
 }
 
@@ -35,23 +44,71 @@ func NewRouter(h *Handler) http.Handler {
 
 	mux.HandleFunc("GET /api/health", h.HealthCheck)
 
-	mux.HandleFunc("GET /api/models", h.ListModels)
-	mux.HandleFunc("POST /api/models/pull", h.PullModel)
-	mux.HandleFunc("DELETE /api/models/{name}", h.DeleteModel)
-
-	mux.HandleFunc("GET /api/conversations", h.ListConversations)
-	mux.HandleFunc("POST /api/conversations", h.CreateConversation)
-	mux.HandleFunc("GET /api/conversations/{id}", h.GetConversation)
-	mux.HandleFunc("PATCH /api/conversations/{id}", h.UpdateConversation)
-	mux.HandleFunc("DELETE /api/conversations/{id}", h.DeleteConversation)
-
-	mux.HandleFunc("GET /api/conversations/{id}/messages", h.GetMessages)
-
-	mux.HandleFunc("POST /api/chat", h.ChatStream)
-
-	mux.HandleFunc("GET /api/stats", h.GetStats)
-
-	return corsMiddleware(logMiddleware(h.logger)(mux))
+	mux.HandleFunc("POST /api/auth/login", h.Login)
+	mux.HandleFunc("POST /api/auth/logout", h.Logout)
+	mux.HandleFunc("GET /api/auth/me", h.Me)
+	mux.Handle("PUT /api/auth/password", requireInteractive(h.ChangePassword))
+
+	mux.Handle("GET /api/tokens", requireInteractive(h.ListAPITokens))
+	mux.Handle("POST /api/tokens", requireInteractive(h.CreateAPIToken))
+	mux.Handle("DELETE /api/tokens/{id}", requireInteractive(h.RevokeAPIToken))
+
+	mux.Handle("GET /api/users", requireScope(ScopeUsersManage, h.ListUsers))
+	mux.Handle("POST /api/users", requireScope(ScopeUsersManage, h.CreateUser))
+	mux.Handle("PATCH /api/users/{id}", requireScope(ScopeUsersManage, h.UpdateUser))
+	mux.Handle("DELETE /api/users/{id}", requireScope(ScopeUsersManage, h.DeleteUser))
+
+	mux.Handle("GET /api/models", requireScope(ScopeModelsRead, h.ListModels))
+	mux.Handle("GET /api/tools", requireScope(ScopeModelsRead, h.ListTools))
+	mux.Handle("POST /api/models/pull", requireScope(ScopeModelsWrite, h.PullModel))
+	mux.Handle("DELETE /api/models/{name...}", requireScope(ScopeModelsWrite, h.DeleteModel))
+
+	mux.Handle("GET /api/conversations", requireScope(ScopeConversationsRead, h.ListConversations))
+	mux.Handle("POST /api/conversations", requireScope(ScopeConversationsWrite, h.CreateConversation))
+	mux.Handle("GET /api/conversations/{id}", requireScope(ScopeConversationsRead, h.GetConversation))
+	mux.Handle("PATCH /api/conversations/{id}", requireScope(ScopeConversationsWrite, h.UpdateConversation))
+	mux.Handle("DELETE /api/conversations/{id}", requireScope(ScopeConversationsWrite, h.DeleteConversation))
+	mux.Handle("GET /api/conversations/{id}/settings", requireScope(ScopeConversationsRead, h.GetConversationSettings))
+	mux.Handle("GET /api/conversations/{id}/export", requireScope(ScopeConversationsRead, h.ExportConversation))
+	mux.Handle("GET /api/export", requireScope(ScopeConversationsRead, h.ExportAll))
+	mux.Handle("POST /api/import", requireScope(ScopeConversationsWrite, h.ImportConversations))
+
+	mux.Handle("POST /api/conversations/{id}/share", requireScope(ScopeConversationsWrite, h.CreateShare))
+	mux.Handle("GET /api/conversations/{id}/shares", requireScope(ScopeConversationsRead, h.ListShares))
+	mux.Handle("DELETE /api/shares/{id}", requireScope(ScopeConversationsWrite, h.RevokeShare))
+	mux.HandleFunc("GET /api/shared/{token}", h.GetSharedConversation)
+	mux.Handle("POST /api/conversations/{id}/restore", requireScope(ScopeConversationsWrite, h.RestoreConversation))
+	mux.Handle("DELETE /api/trash", requireScope(ScopeConversationsWrite, h.EmptyTrash))
+
+	mux.Handle("GET /api/tags", requireScope(ScopeConversationsRead, h.ListTags))
+	mux.Handle("POST /api/tags", requireScope(ScopeConversationsWrite, h.CreateTag))
+	mux.Handle("PATCH /api/tags/{id}", requireScope(ScopeConversationsWrite, h.UpdateTag))
+	mux.Handle("DELETE /api/tags/{id}", requireScope(ScopeConversationsWrite, h.DeleteTag))
+
+	mux.Handle("GET /api/folders", requireScope(ScopeConversationsRead, h.ListFolders))
+	mux.Handle("POST /api/folders", requireScope(ScopeConversationsWrite, h.CreateFolder))
+	mux.Handle("PATCH /api/folders/{id}", requireScope(ScopeConversationsWrite, h.UpdateFolder))
+	mux.Handle("DELETE /api/folders/{id}", requireScope(ScopeConversationsWrite, h.DeleteFolder))
+
+	mux.Handle("GET /api/attachments/{id}", requireScope(ScopeConversationsRead, h.GetAttachment))
+
+	mux.Handle("GET /api/conversations/{id}/messages", requireScope(ScopeConversationsRead, h.GetMessages))
+	mux.Handle("POST /api/conversations/{id}/messages/{messageId}/edit", requireScope(ScopeChat, h.EditMessage))
+	mux.Handle("GET /api/conversations/{id}/branches", requireScope(ScopeConversationsRead, h.ListBranches))
+	mux.Handle("PUT /api/conversations/{id}/branch", requireScope(ScopeConversationsWrite, h.SwitchBranch))
+
+	mux.Handle("POST /api/chat", requireScope(ScopeChat, h.ChatStream))
+	mux.Handle("POST /api/conversations/{id}/regenerate", requireScope(ScopeChat, h.RegenerateResponse))
+	mux.Handle("POST /api/conversations/{id}/stop", requireScope(ScopeChat, h.StopGeneration))
+
+	mux.Handle("GET /api/search", requireScope(ScopeConversationsRead, h.Search))
+
+	mux.Handle("GET /api/stats", requireScope(ScopeConversationsRead, h.GetStats))
+
+	mux.Handle("GET /v1/models", requireScope(ScopeModelsRead, h.OpenAIListModels))
+	mux.Handle("POST /v1/chat/completions", requireScope(ScopeChat, h.OpenAIChatCompletions))
+
+	return corsMiddleware(logMiddleware(h.logger)(h.authMiddleware(mux)))
 }
 
 func corsHandler(cfg *config.Config) func(http.Handler) http.Handler {
@@ -0,0 +1,71 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ifauzeee/Zee-AI/internal/db"
+)
+
+const maxSearchResults = 100
+
+func (h *Handler) Search(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	opts := db.SearchOptions{
+		Query: strings.TrimSpace(q.Get("q")),
+		Model: q.Get("model"),
+		Role:  q.Get("role"),
+		Limit: 20,
+	}
+	if opts.Query == "" {
+		writeError(w, http.StatusBadRequest, "Query parameter q is required")
+		return
+	}
+	switch opts.Role {
+	case "", "user", "assistant", "system":
+	default:
+		writeError(w, http.StatusBadRequest, "role must be one of user, assistant, system")
+		return
+	}
+	limit, from, to, ok := parsePageParams(w, r)
+	if !ok {
+		return
+	}
+	if limit > 0 {
+		opts.Limit = min(limit, maxSearchResults)
+	}
+	opts.From, opts.To = from, to
+
+	results, err := h.db.Search(currentUser(r).ID, opts)
+	if err != nil {
+		h.logger.Error("search failed", "error", err)
+		writeError(w, http.StatusInternalServerError, "Search failed")
+		return
+	}
+	if results == nil {
+		results = []db.SearchResult{}
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"query":   opts.Query,
+		"results": results,
+	})
+}
+
+func parseDateParam(v string, endOfDay bool) (*time.Time, error) {
+	if v == "" {
+		return nil, nil
+	}
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		t = t.Local()
+		return &t, nil
+	}
+	t, err := time.ParseInLocation("2006-01-02", v, time.Local)
+	if err != nil {
+		return nil, err
+	}
+	if endOfDay {
+		t = t.AddDate(0, 0, 1)
+	}
+	return &t, nil
+}
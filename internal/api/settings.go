@@ -0,0 +1,127 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/ifauzeee/Zee-AI/internal/db"
+	"github.com/ifauzeee/Zee-AI/internal/ollama"
+)
+
+type settingsUpdate struct {
+	SystemPrompt *string         `json:"system_prompt"`
+	Model        *string         `json:"model"`
+	Options      json.RawMessage `json:"options"`
+}
+
+func (u *settingsUpdate) apply(current *db.ConversationSettings) (*db.ConversationSettings, error) {
+	next := &db.ConversationSettings{}
+	if current != nil {
+		next.SystemPrompt = current.SystemPrompt
+		next.Model = current.Model
+		next.Options = current.Options
+	}
+	if u.SystemPrompt != nil {
+		next.SystemPrompt = *u.SystemPrompt
+	}
+	if u.Model != nil {
+		next.Model = strings.TrimSpace(*u.Model)
+	}
+	if u.Options != nil {
+		opts, err := parseOptions(u.Options)
+		if err != nil {
+			return nil, err
+		}
+		next.Options = encodeOptions(opts)
+	}
+
+	if current != nil && next.SystemPrompt == current.SystemPrompt && next.Model == current.Model && bytes.Equal(next.Options, current.Options) {
+		return nil, nil
+	}
+	return next, nil
+}
+
+func encodeOptions(o *ollama.Options) json.RawMessage {
+	if o == nil {
+		return nil
+	}
+	data, err := json.Marshal(o)
+	if err != nil || string(data) == "{}" {
+		return nil
+	}
+	return data
+}
+
+func mergeOptions(saved json.RawMessage, override *ollama.Options) *ollama.Options {
+	if len(saved) == 0 {
+		return override
+	}
+	var merged ollama.Options
+	json.Unmarshal(saved, &merged)
+	if override != nil {
+		data, _ := json.Marshal(override)
+		json.Unmarshal(data, &merged)
+	}
+	return &merged
+}
+
+func withSystemPrompt(history []db.Message, prompt *string) []db.Message {
+	if prompt == nil {
+		return history
+	}
+	out := make([]db.Message, 0, len(history)+1)
+	if *prompt != "" {
+		out = append(out, db.Message{Role: "system", Content: *prompt})
+	}
+	for _, m := range history {
+		if m.Role != "system" {
+			out = append(out, m)
+		}
+	}
+	return out
+}
+
+func (h *Handler) conversationSettings(conversationID string) *db.ConversationSettings {
+	s, err := h.db.GetConversationSettings(conversationID)
+	if err != nil {
+		if !errors.Is(err, db.ErrNotFound) {
+			h.logger.Error("load conversation settings failed", "error", err)
+		}
+		return nil
+	}
+	return s
+}
+
+func (p *replyParams) applySettings(s *db.ConversationSettings) {
+	if s == nil {
+		return
+	}
+	p.options = mergeOptions(s.Options, p.options)
+	p.systemPrompt = &s.SystemPrompt
+	p.settingsVersion = s.Version
+}
+
+func (h *Handler) GetConversationSettings(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if _, err := h.db.GetConversation(id, currentUser(r).ID); err != nil {
+		writeError(w, http.StatusNotFound, "Conversation not found")
+		return
+	}
+
+	versions, err := h.db.ListConversationSettings(id)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to get settings")
+		return
+	}
+	var current *db.ConversationSettings
+	if n := len(versions); n > 0 {
+		current = &versions[n-1]
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"settings": current,
+		"versions": versions,
+	})
+}
@@ -0,0 +1,127 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestConversationSettings(t *testing.T) {
+	fake := newFakeOllama(t, "ok")
+	router, database := newTestRouterWithOllama(t, fake.URL)
+	headers := map[string]string{"Authorization": "Bearer s3cret"}
+
+	rec := doRequest(t, router, http.MethodPost, "/api/chat", headers, map[string]interface{}{
+		"model":         "llama3",
+		"message":       "hi",
+		"system_prompt": "Be terse.",
+		"options":       map[string]interface{}{"temperature": 0.2},
+	})
+	data, _ := strings.CutPrefix(strings.SplitN(rec.Body.String(), "\n", 2)[0], "data: ")
+	var init struct {
+		ConversationID string `json:"conversation_id"`
+	}
+	json.Unmarshal([]byte(data), &init)
+	id := init.ConversationID
+
+	rec = doRequest(t, router, http.MethodPost, "/api/chat", headers, map[string]interface{}{
+		"conversation_id": id,
+		"message":         "again",
+	})
+	last := fake.lastChat(t, true)
+	if rec.Code != http.StatusOK || last.Model != "llama3" || last.Options == nil || *last.Options.Temperature != 0.2 {
+		t.Fatalf("follow-up = %d, request %+v", rec.Code, last)
+	}
+	if msgs := last.Messages; msgs[0].Role != "system" || msgs[0].Content != "Be terse." {
+		t.Fatalf("follow-up messages = %+v", msgs)
+	}
+
+	rec = doRequest(t, router, http.MethodPatch, "/api/conversations/"+id, headers, map[string]interface{}{
+		"settings": map[string]interface{}{"options": map[string]interface{}{"temperature": 9}},
+	})
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("invalid settings = %d, want 400", rec.Code)
+	}
+	rec = doRequest(t, router, http.MethodPatch, "/api/conversations/"+id, headers, map[string]interface{}{
+		"settings": map[string]interface{}{
+			"system_prompt": "Be thorough.",
+			"model":         "mistral",
+			"options":       map[string]interface{}{"num_ctx": 2048},
+		},
+	})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("patch settings = %d: %s", rec.Code, rec.Body)
+	}
+	rec = doRequest(t, router, http.MethodPatch, "/api/conversations/"+id, headers, map[string]interface{}{
+		"settings": map[string]interface{}{"model": "mistral"},
+	})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("unchanged settings = %d", rec.Code)
+	}
+	rec = doRequest(t, router, http.MethodPatch, "/api/conversations/missing", headers, map[string]interface{}{
+		"settings": map[string]interface{}{"model": "llama3"},
+	})
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("unknown conversation = %d, want 404", rec.Code)
+	}
+
+	rec = doRequest(t, router, http.MethodPost, "/api/chat", headers, map[string]interface{}{
+		"conversation_id": id,
+		"message":         "and now?",
+		"options":         map[string]interface{}{"seed": 0},
+	})
+	last = fake.lastChat(t, true)
+	o := last.Options
+	if rec.Code != http.StatusOK || last.Model != "mistral" || o.Temperature != nil || o.NumCtx == nil || *o.NumCtx != 2048 || o.Seed == nil {
+		t.Fatalf("after patch = %d, request %+v", rec.Code, last)
+	}
+	if msgs := last.Messages; msgs[0].Content != "Be thorough." || msgs[1].Role == "system" {
+		t.Fatalf("after patch messages = %+v", msgs)
+	}
+
+	rec = doRequest(t, router, http.MethodGet, "/api/conversations/"+id+"/settings", headers, nil)
+	var resp struct {
+		Settings struct {
+			Version int    `json:"version"`
+			Model   string `json:"model"`
+		} `json:"settings"`
+		Versions []json.RawMessage `json:"versions"`
+	}
+	json.Unmarshal(rec.Body.Bytes(), &resp)
+	if resp.Settings.Version != 2 || resp.Settings.Model != "mistral" || len(resp.Versions) != 2 {
+		t.Fatalf("settings = %s", rec.Body)
+	}
+
+	msgs, _ := database.GetMessages(id, "admin-id")
+	var versions []int
+	var options []string
+	for _, m := range msgs {
+		if m.Role == "assistant" {
+			versions = append(versions, m.SettingsVersion)
+			options = append(options, string(m.Options))
+		}
+	}
+	if len(versions) != 3 || versions[0] != 1 || versions[1] != 1 || versions[2] != 2 {
+		t.Fatalf("assistant settings versions = %v", versions)
+	}
+	if options[0] != `{"temperature":0.2}` || options[2] != `{"seed":0,"num_ctx":2048}` {
+		t.Fatalf("assistant options = %v", options)
+	}
+
+	rec = doRequest(t, router, http.MethodPatch, "/api/conversations/"+id, headers, map[string]interface{}{
+		"settings": map[string]interface{}{"system_prompt": ""},
+	})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("clear system prompt = %d", rec.Code)
+	}
+	doRequest(t, router, http.MethodPost, "/api/chat", headers, map[string]interface{}{
+		"conversation_id": id,
+		"message":         "no prompt now",
+	})
+	for _, m := range fake.lastChat(t, true).Messages {
+		if m.Role == "system" {
+			t.Fatalf("cleared system prompt still sent: %+v", m)
+		}
+	}
+}
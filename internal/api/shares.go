@@ -0,0 +1,176 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ifauzeee/Zee-AI/internal/auth"
+	"github.com/ifauzeee/Zee-AI/internal/db"
+)
+
+type sharedMessage struct {
+	Role      string    `json:"role"`
+	Content   string    `json:"content"`
+	Model     string    `json:"model,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type sharedConversation struct {
+	Title     string          `json:"title"`
+	Model     string          `json:"model,omitempty"`
+	SharedAt  time.Time       `json:"shared_at"`
+	ExpiresAt *time.Time      `json:"expires_at,omitempty"`
+	Messages  []sharedMessage `json:"messages"`
+}
+
+func (h *Handler) CreateShare(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		IncludeSystem bool       `json:"include_system"`
+		ExpiresIn     string     `json:"expires_in,omitempty"`
+		ExpiresAt     *time.Time `json:"expires_at,omitempty"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	expiresAt, ok := parseExpiry(w, req.ExpiresIn, req.ExpiresAt)
+	if !ok {
+		return
+	}
+
+	id := r.PathValue("id")
+	userID := currentUser(r).ID
+	convo, err := h.db.GetConversation(id, userID)
+	if err != nil || convo.DeletedAt != nil {
+		writeError(w, http.StatusNotFound, "Conversation not found")
+		return
+	}
+	branch, err := h.db.GetBranch(id, userID, convo.ActiveMessageID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to share conversation")
+		return
+	}
+	if settings := h.conversationSettings(id); settings != nil {
+		branch = withSystemPrompt(branch, &settings.SystemPrompt)
+		if len(branch) > 0 && branch[0].Role == "system" {
+			branch[0].CreatedAt = settings.CreatedAt
+		}
+	}
+
+	now := time.Now()
+	snapshot := sharedConversation{
+		Title:    convo.Title,
+		Model:    convo.Model,
+		SharedAt: now,
+		Messages: []sharedMessage{},
+	}
+	for _, m := range branch {
+		if m.Role == "system" && !req.IncludeSystem {
+			continue
+		}
+		if m.Role == "tool" || (len(m.ToolCalls) > 0 && m.Content == "") {
+			continue
+		}
+		snapshot.Messages = append(snapshot.Messages, sharedMessage{
+			Role:      m.Role,
+			Content:   m.Content,
+			Model:     m.Model,
+			CreatedAt: m.CreatedAt,
+		})
+	}
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to share conversation")
+		return
+	}
+
+	secret, err := auth.NewToken()
+	if err != nil {
+		h.logger.Error("generate share token failed", "error", err)
+		writeError(w, http.StatusInternalServerError, "Failed to share conversation")
+		return
+	}
+	share := &db.Share{
+		ID:             uuid.New().String(),
+		UserID:         userID,
+		ConversationID: id,
+		TokenHash:      auth.HashToken(secret),
+		Prefix:         secret[:8],
+		IncludeSystem:  req.IncludeSystem,
+		Snapshot:       string(data),
+		CreatedAt:      now,
+		ExpiresAt:      expiresAt,
+	}
+	if err := h.db.CreateShare(share); err != nil {
+		h.logger.Error("create share failed", "error", err)
+		writeError(w, http.StatusInternalServerError, "Failed to share conversation")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, map[string]interface{}{
+		"token": secret,
+		"path":  "/api/shared/" + secret,
+		"share": share,
+	})
+}
+
+func (h *Handler) ListShares(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	userID := currentUser(r).ID
+	if _, err := h.db.GetConversation(id, userID); err != nil {
+		writeError(w, http.StatusNotFound, "Conversation not found")
+		return
+	}
+
+	shares, err := h.db.ListShares(id, userID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to list shares")
+		return
+	}
+	if shares == nil {
+		shares = []db.Share{}
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"shares": shares,
+	})
+}
+
+func (h *Handler) RevokeShare(w http.ResponseWriter, r *http.Request) {
+	if err := h.db.RevokeShare(r.PathValue("id"), currentUser(r).ID); err != nil {
+		if errors.Is(err, db.ErrNotFound) {
+			writeError(w, http.StatusNotFound, "Share not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "Failed to revoke share")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"status": "revoked"})
+}
+
+func (h *Handler) GetSharedConversation(w http.ResponseWriter, r *http.Request) {
+	share, err := h.db.GetShareByHash(auth.HashToken(r.PathValue("token")))
+	if err != nil {
+		if !errors.Is(err, db.ErrNotFound) {
+			h.logger.Error("get share failed", "error", err)
+		}
+		writeError(w, http.StatusNotFound, "Shared conversation not found")
+		return
+	}
+	if !share.Active(time.Now()) {
+		writeError(w, http.StatusNotFound, "Shared conversation not found")
+		return
+	}
+
+	var snapshot sharedConversation
+	if err := json.Unmarshal([]byte(share.Snapshot), &snapshot); err != nil {
+		h.logger.Error("decode share snapshot failed", "id", share.ID, "error", err)
+		writeError(w, http.StatusInternalServerError, "Failed to load shared conversation")
+		return
+	}
+	snapshot.ExpiresAt = share.ExpiresAt
+	writeJSON(w, http.StatusOK, snapshot)
+}
@@ -0,0 +1,101 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/ifauzeee/Zee-AI/internal/db"
+)
+
+func TestShareLinks(t *testing.T) {
+	router, database := newTestRouter(t)
+	headers := map[string]string{"X-API-Key": "s3cret"}
+
+	if _, err := database.CreateConversation("conv", "admin-id", "Shared chat", "llama"); err != nil {
+		t.Fatalf("create conversation: %v", err)
+	}
+	for _, m := range []*db.Message{
+		{ID: "s", ConversationID: "conv", Role: "system", Content: "secret instructions"},
+		{ID: "u", ConversationID: "conv", ParentID: "s", Role: "user", Content: "hello"},
+		{ID: "c", ConversationID: "conv", ParentID: "u", Role: "assistant", ToolCalls: json.RawMessage(`[{"function":{"name":"search_conversations","arguments":{"query":"salary"}}}]`)},
+		{ID: "r", ConversationID: "conv", ParentID: "c", Role: "tool", ToolName: "search_conversations", Content: "Private chat: my salary is 90k"},
+		{ID: "a", ConversationID: "conv", ParentID: "r", Role: "assistant", Content: "hi there"},
+	} {
+		if err := database.CreateMessage(m); err != nil {
+			t.Fatalf("create message: %v", err)
+		}
+	}
+
+	share := func(body interface{}) (string, string) {
+		t.Helper()
+		rec := doRequest(t, router, http.MethodPost, "/api/conversations/conv/share", headers, body)
+		if rec.Code != http.StatusCreated {
+			t.Fatalf("share = %d: %s", rec.Code, rec.Body)
+		}
+		var resp struct {
+			Token string   `json:"token"`
+			Share db.Share `json:"share"`
+		}
+		json.Unmarshal(rec.Body.Bytes(), &resp)
+		return resp.Token, resp.Share.ID
+	}
+
+	token, shareID := share(nil)
+	if err := database.CreateMessage(&db.Message{ID: "u2", ConversationID: "conv", ParentID: "a", Role: "user", Content: "added later"}); err != nil {
+		t.Fatalf("create message: %v", err)
+	}
+
+	rec := doRequest(t, router, http.MethodGet, "/api/shared/"+token, nil, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("get shared = %d: %s", rec.Code, rec.Body)
+	}
+	body := rec.Body.String()
+	if strings.Contains(body, "secret instructions") || strings.Contains(body, "added later") || strings.Contains(body, "admin-id") ||
+		strings.Contains(body, "salary") {
+		t.Fatalf("shared snapshot leaked data: %s", body)
+	}
+	var snapshot sharedConversation
+	json.Unmarshal(rec.Body.Bytes(), &snapshot)
+	if snapshot.Title != "Shared chat" || len(snapshot.Messages) != 2 {
+		t.Fatalf("snapshot = %+v", snapshot)
+	}
+
+	withSystem, _ := share(map[string]bool{"include_system": true})
+	rec = doRequest(t, router, http.MethodGet, "/api/shared/"+withSystem, nil, nil)
+	if !strings.Contains(rec.Body.String(), "secret instructions") {
+		t.Fatalf("include_system share omitted the system prompt: %s", rec.Body)
+	}
+
+	rec = doRequest(t, router, http.MethodPatch, "/api/conversations/conv", headers, map[string]interface{}{
+		"settings": map[string]string{"system_prompt": "answer like a pirate"},
+	})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("patch settings = %d: %s", rec.Code, rec.Body)
+	}
+	withSettings, _ := share(map[string]bool{"include_system": true})
+	rec = doRequest(t, router, http.MethodGet, "/api/shared/"+withSettings, nil, nil)
+	json.Unmarshal(rec.Body.Bytes(), &snapshot)
+	if m := snapshot.Messages[0]; m.Role != "system" || m.Content != "answer like a pirate" || strings.Contains(rec.Body.String(), "secret instructions") {
+		t.Fatalf("settings prompt share = %s", rec.Body)
+	}
+	withoutSystem, _ := share(nil)
+	rec = doRequest(t, router, http.MethodGet, "/api/shared/"+withoutSystem, nil, nil)
+	if strings.Contains(rec.Body.String(), "pirate") {
+		t.Fatalf("share without include_system leaked the settings prompt: %s", rec.Body)
+	}
+
+	if rec := doRequest(t, router, http.MethodDelete, "/api/shares/"+shareID, headers, nil); rec.Code != http.StatusOK {
+		t.Fatalf("revoke = %d", rec.Code)
+	}
+	if rec := doRequest(t, router, http.MethodGet, "/api/shared/"+token, nil, nil); rec.Code != http.StatusNotFound {
+		t.Fatalf("revoked share = %d, want 404", rec.Code)
+	}
+	if rec := doRequest(t, router, http.MethodGet, "/api/shared/not-a-token", nil, nil); rec.Code != http.StatusNotFound {
+		t.Fatalf("unknown share = %d, want 404", rec.Code)
+	}
+	if rec := doRequest(t, router, http.MethodPost, "/api/conversations/conv/share", headers, map[string]string{"expires_in": "-1h"}); rec.Code != http.StatusBadRequest {
+		t.Fatalf("past expiry = %d, want 400", rec.Code)
+	}
+}
@@ -0,0 +1,97 @@
+package api
+
+import (
+	"context"
+	"time"
+
+	"github.com/ifauzeee/Zee-AI/internal/db"
+	"github.com/ifauzeee/Zee-AI/internal/ollama"
+	"github.com/ifauzeee/Zee-AI/internal/provider"
+)
+
+const (
+	summaryKeepRecent = 2
+	summaryTimeout    = 2 * time.Minute
+)
+
+type storedSummary struct {
+	text   string
+	covers int
+}
+
+func summaryFor(convo *db.Conversation, history []db.Message) storedSummary {
+	s := storedSummary{covers: -1}
+	if convo.Summary == "" || convo.SummaryMessageID == "" {
+		return s
+	}
+	for i, m := range history {
+		if m.ID == convo.SummaryMessageID {
+			s.text = convo.Summary
+			s.covers = i
+			break
+		}
+	}
+	return s
+}
+
+func (h *Handler) refreshSummary(conversationID, userID, model string, force bool) {
+	if h.cfg.SummaryInterval <= 0 && !force {
+		return
+	}
+	if _, busy := h.summarizing.LoadOrStore(conversationID, struct{}{}); busy {
+		return
+	}
+	defer h.summarizing.Delete(conversationID)
+
+	convo, err := h.db.GetConversation(conversationID, userID)
+	if err != nil {
+		return
+	}
+	branch, err := h.db.GetBranch(conversationID, userID, convo.ActiveMessageID)
+	if err != nil {
+		return
+	}
+
+	stored := summaryFor(convo, branch)
+	turns := 0
+	for _, m := range branch[stored.covers+1:] {
+		if m.Role == "user" {
+			turns++
+		}
+	}
+	if turns < h.cfg.SummaryInterval && !force {
+		return
+	}
+
+	end := len(branch) - summaryKeepRecent
+	if end <= stored.covers+1 {
+		return
+	}
+	var pending []ollama.ChatMessage
+	for _, m := range branch[stored.covers+1 : end] {
+		if m.Role == "system" {
+			continue
+		}
+		pending = append(pending, ollama.ChatMessage{Role: m.Role, Content: m.Content})
+	}
+	if len(pending) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), summaryTimeout)
+	defer cancel()
+
+	summary, err := provider.Summarize(ctx, h.llm, model, stored.text, pending)
+	if err != nil {
+		h.logger.Warn("refresh conversation summary failed", "conversation_id", conversationID, "error", err)
+		return
+	}
+	if summary == "" {
+		return
+	}
+	if err := h.db.UpdateConversationSummary(conversationID, summary, branch[end-1].ID); err != nil {
+		h.logger.Error("save conversation summary failed", "conversation_id", conversationID, "error", err)
+		return
+	}
+	h.logger.Info("conversation summary refreshed", "conversation_id", conversationID, "through", branch[end-1].ID)
+}
@@ -0,0 +1,64 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ifauzeee/Zee-AI/internal/config"
+	"github.com/ifauzeee/Zee-AI/internal/db"
+)
+
+func TestRefreshSummaryEveryInterval(t *testing.T) {
+	for _, tc := range []struct {
+		strategy string
+		interval int
+		force    bool
+		want     string
+	}{
+		{StrategySystemRecent, 2, false, "Title"},
+		{StrategySlidingWindow, 2, false, "Title"},
+		{StrategySummarize, 2, false, "Title"},
+		{StrategySystemRecent, 4, false, ""},
+		{StrategySystemRecent, 0, false, ""},
+		{StrategySummarize, 0, true, "Title"},
+		{StrategySummarize, 4, true, "Title"},
+	} {
+		database, err := db.New(filepath.Join(t.TempDir(), "test.db"))
+		if err != nil {
+			t.Fatalf("open database: %v", err)
+		}
+		defer database.Close()
+		if err := database.CreateUser(&db.User{ID: "admin-id", Username: "admin", Role: db.RoleAdmin}); err != nil {
+			t.Fatal(err)
+		}
+
+		database.CreateConversation("c1", "admin-id", "Long chat", "llama3")
+		parent := ""
+		for i, role := range []string{"user", "assistant", "user", "assistant", "user", "assistant"} {
+			m := &db.Message{ID: fmt.Sprintf("m%d", i), ConversationID: "c1", ParentID: parent, Role: role, Content: "turn", CreatedAt: time.Now()}
+			if err := database.CreateMessage(m); err != nil {
+				t.Fatal(err)
+			}
+			parent = m.ID
+		}
+
+		cfg := &config.Config{APISecretKey: "s3cret", AdminUsername: "admin", ContextStrategy: tc.strategy, SummaryInterval: tc.interval}
+		h := NewHandler(database, newBlockingLLM(false), cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+		h.refreshSummary("c1", "admin-id", "llama3", tc.force)
+
+		rec := doRequest(t, NewRouter(h), http.MethodGet, "/api/conversations/c1", map[string]string{"X-API-Key": "s3cret"}, nil)
+		var resp struct {
+			Conversation db.Conversation `json:"conversation"`
+		}
+		json.Unmarshal(rec.Body.Bytes(), &resp)
+		if rec.Code != http.StatusOK || resp.Conversation.Summary != tc.want {
+			t.Errorf("%s every %d turns (force %v): GET = %d, summary %q, want %q", tc.strategy, tc.interval, tc.force, rec.Code, resp.Conversation.Summary, tc.want)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/ifauzeee/Zee-AI/internal/db"
+)
+
+func (h *Handler) ListTags(w http.ResponseWriter, r *http.Request) {
+	tags, err := h.db.ListTags(currentUser(r).ID)
+	if err != nil {
+		h.logger.Error("list tags failed", "error", err)
+		writeError(w, http.StatusInternalServerError, "Failed to list tags")
+		return
+	}
+	if tags == nil {
+		tags = []db.Tag{}
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"tags": tags,
+	})
+}
+
+func (h *Handler) CreateTag(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Name  string `json:"name"`
+		Color string `json:"color"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		writeError(w, http.StatusBadRequest, "Name is required")
+		return
+	}
+
+	userID := currentUser(r).ID
+	if _, err := h.db.GetTagByName(userID, req.Name); err == nil {
+		writeError(w, http.StatusConflict, "Tag already exists")
+		return
+	}
+
+	tag := &db.Tag{
+		ID:     uuid.New().String(),
+		UserID: userID,
+		Name:   req.Name,
+		Color:  req.Color,
+	}
+	if err := h.db.CreateTag(tag); err != nil {
+		h.logger.Error("create tag failed", "error", err)
+		writeError(w, http.StatusInternalServerError, "Failed to create tag")
+		return
+	}
+	writeJSON(w, http.StatusCreated, tag)
+}
+
+func (h *Handler) UpdateTag(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	userID := currentUser(r).ID
+	tag, err := h.db.GetTag(id, userID)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "Tag not found")
+		return
+	}
+
+	var req struct {
+		Name  *string `json:"name"`
+		Color *string `json:"color"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		if name == "" {
+			writeError(w, http.StatusBadRequest, "Name is required")
+			return
+		}
+		if existing, err := h.db.GetTagByName(userID, name); err == nil && existing.ID != id {
+			writeError(w, http.StatusConflict, "Tag already exists")
+			return
+		}
+		tag.Name = name
+	}
+	if req.Color != nil {
+		tag.Color = *req.Color
+	}
+
+	if err := h.db.UpdateTag(id, userID, tag.Name, tag.Color); err != nil {
+		if errors.Is(err, db.ErrNotFound) {
+			writeError(w, http.StatusNotFound, "Tag not found")
+			return
+		}
+		h.logger.Error("update tag failed", "error", err)
+		writeError(w, http.StatusInternalServerError, "Failed to update tag")
+		return
+	}
+	writeJSON(w, http.StatusOK, tag)
+}
+
+func (h *Handler) DeleteTag(w http.ResponseWriter, r *http.Request) {
+	if err := h.db.DeleteTag(r.PathValue("id"), currentUser(r).ID); err != nil {
+		if errors.Is(err, db.ErrNotFound) {
+			writeError(w, http.StatusNotFound, "Tag not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "Failed to delete tag")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"status": "deleted"})
+}
@@ -0,0 +1,150 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ifauzeee/Zee-AI/internal/auth"
+	"github.com/ifauzeee/Zee-AI/internal/db"
+)
+
+const (
+	ScopeConversationsRead  = "conversations:read"
+	ScopeConversationsWrite = "conversations:write"
+	ScopeChat               = "chat"
+	ScopeModelsRead         = "models:read"
+	ScopeModelsWrite        = "models:write"
+	ScopeUsersManage        = "users:manage"
+)
+
+var scopeRoles = map[string]db.Role{
+	ScopeConversationsRead:  db.RoleReadOnly,
+	ScopeConversationsWrite: db.RoleMember,
+	ScopeChat:               db.RoleMember,
+	ScopeModelsRead:         db.RoleReadOnly,
+	ScopeModelsWrite:        db.RoleAdmin,
+	ScopeUsersManage:        db.RoleAdmin,
+}
+
+func (h *Handler) ListAPITokens(w http.ResponseWriter, r *http.Request) {
+	tokens, err := h.db.ListAPITokens(currentUser(r).ID)
+	if err != nil {
+		h.logger.Error("list api tokens failed", "error", err)
+		writeError(w, http.StatusInternalServerError, "Failed to list API tokens")
+		return
+	}
+	if tokens == nil {
+		tokens = []db.APIToken{}
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"tokens": tokens,
+	})
+}
+
+func (h *Handler) CreateAPIToken(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Name      string     `json:"name"`
+		Scopes    []string   `json:"scopes"`
+		ExpiresIn string     `json:"expires_in,omitempty"`
+		ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		writeError(w, http.StatusBadRequest, "Token name is required")
+		return
+	}
+	if len(req.Scopes) == 0 {
+		writeError(w, http.StatusBadRequest, "At least one scope is required")
+		return
+	}
+
+	user := currentUser(r)
+	seen := make(map[string]bool)
+	var scopes []string
+	for _, scope := range req.Scopes {
+		min, ok := scopeRoles[scope]
+		if !ok {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("Unknown scope %q", scope))
+			return
+		}
+		if !user.Role.AtLeast(min) {
+			writeError(w, http.StatusForbidden, fmt.Sprintf("Scope %q requires the %s role", scope, min))
+			return
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			scopes = append(scopes, scope)
+		}
+	}
+
+	expiresAt, ok := parseExpiry(w, req.ExpiresIn, req.ExpiresAt)
+	if !ok {
+		return
+	}
+
+	secret, err := auth.NewAPIToken()
+	if err != nil {
+		h.logger.Error("generate api token failed", "error", err)
+		writeError(w, http.StatusInternalServerError, "Failed to create API token")
+		return
+	}
+	token := &db.APIToken{
+		ID:        uuid.New().String(),
+		UserID:    user.ID,
+		Name:      req.Name,
+		TokenHash: auth.HashToken(secret),
+		Prefix:    secret[:len(auth.APITokenPrefix)+8],
+		Scopes:    scopes,
+		ExpiresAt: expiresAt,
+	}
+	if err := h.db.CreateAPIToken(token); err != nil {
+		h.logger.Error("create api token failed", "error", err)
+		writeError(w, http.StatusInternalServerError, "Failed to create API token")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, map[string]interface{}{
+		"token":     secret,
+		"api_token": token,
+	})
+}
+
+func (h *Handler) RevokeAPIToken(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if err := h.db.RevokeAPIToken(id, currentUser(r).ID); err != nil {
+		if errors.Is(err, db.ErrNotFound) {
+			writeError(w, http.StatusNotFound, "API token not found")
+			return
+		}
+		h.logger.Error("revoke api token failed", "error", err)
+		writeError(w, http.StatusInternalServerError, "Failed to revoke API token")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"status": "revoked"})
+}
+
+func parseExpiry(w http.ResponseWriter, expiresIn string, expiresAt *time.Time) (*time.Time, bool) {
+	if expiresIn != "" {
+		d, err := time.ParseDuration(expiresIn)
+		if err != nil || d <= 0 {
+			writeError(w, http.StatusBadRequest, "expires_in must be a positive duration like 720h")
+			return nil, false
+		}
+		t := time.Now().Add(d)
+		expiresAt = &t
+	}
+	if expiresAt != nil && !expiresAt.After(time.Now()) {
+		writeError(w, http.StatusBadRequest, "Expiry must be in the future")
+		return nil, false
+	}
+	return expiresAt, true
+}
@@ -0,0 +1,93 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+func createToken(t *testing.T, router http.Handler, headers map[string]string, body map[string]interface{}) (string, string) {
+	t.Helper()
+
+	rec := doRequest(t, router, http.MethodPost, "/api/tokens", headers, body)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("create token: status = %d, body = %s", rec.Code, rec.Body)
+	}
+	var resp struct {
+		Token    string `json:"token"`
+		APIToken struct {
+			ID string `json:"id"`
+		} `json:"api_token"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode token: %v", err)
+	}
+	return resp.Token, resp.APIToken.ID
+}
+
+func TestAPITokenScopes(t *testing.T) {
+	router, _ := newTestRouter(t)
+	alice := map[string]string{"Authorization": "Bearer " + login(t, router, "alice")}
+
+	secret, id := createToken(t, router, alice, map[string]interface{}{
+		"name":   "ci",
+		"scopes": []string{ScopeConversationsRead},
+	})
+	withToken := map[string]string{"Authorization": "Bearer " + secret}
+
+	if rec := doRequest(t, router, http.MethodGet, "/api/conversations", withToken, nil); rec.Code != http.StatusOK {
+		t.Fatalf("token with scope: status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	if rec := doRequest(t, router, http.MethodPost, "/api/conversations", withToken, map[string]string{}); rec.Code != http.StatusForbidden {
+		t.Fatalf("token without scope: status = %d, want %d", rec.Code, http.StatusForbidden)
+	}
+	if rec := doRequest(t, router, http.MethodPost, "/api/tokens", withToken, map[string]interface{}{
+		"name":   "nested",
+		"scopes": []string{ScopeConversationsRead},
+	}); rec.Code != http.StatusForbidden {
+		t.Fatalf("token minting tokens: status = %d, want %d", rec.Code, http.StatusForbidden)
+	}
+
+	rec := doRequest(t, router, http.MethodGet, "/api/tokens", alice, nil)
+	var list struct {
+		Tokens []struct {
+			ID         string  `json:"id"`
+			LastUsedAt *string `json:"last_used_at"`
+		} `json:"tokens"`
+	}
+	json.NewDecoder(rec.Body).Decode(&list)
+	if len(list.Tokens) != 1 || list.Tokens[0].ID != id || list.Tokens[0].LastUsedAt == nil {
+		t.Fatalf("unexpected token list: %+v", list.Tokens)
+	}
+
+	if rec := doRequest(t, router, http.MethodDelete, "/api/tokens/"+id, alice, nil); rec.Code != http.StatusOK {
+		t.Fatalf("revoke token: status = %d", rec.Code)
+	}
+	if rec := doRequest(t, router, http.MethodGet, "/api/conversations", withToken, nil); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("revoked token: status = %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+}
+
+func TestAPITokenCreateValidation(t *testing.T) {
+	router, _ := newTestRouter(t)
+	alice := map[string]string{"Authorization": "Bearer " + login(t, router, "alice")}
+
+	tests := []struct {
+		name string
+		body map[string]interface{}
+		want int
+	}{
+		{"unknown scope", map[string]interface{}{"name": "x", "scopes": []string{"everything"}}, http.StatusBadRequest},
+		{"scope above role", map[string]interface{}{"name": "x", "scopes": []string{ScopeModelsWrite}}, http.StatusForbidden},
+		{"missing scopes", map[string]interface{}{"name": "x"}, http.StatusBadRequest},
+		{"bad expiry", map[string]interface{}{"name": "x", "scopes": []string{ScopeChat}, "expires_in": "soon"}, http.StatusBadRequest},
+		{"past expiry", map[string]interface{}{"name": "x", "scopes": []string{ScopeChat}, "expires_at": "2001-01-01T00:00:00Z"}, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if rec := doRequest(t, router, http.MethodPost, "/api/tokens", alice, tt.body); rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d (body %s)", rec.Code, tt.want, rec.Body)
+			}
+		})
+	}
+}
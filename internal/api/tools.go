@@ -0,0 +1,41 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/ifauzeee/Zee-AI/internal/db"
+	"github.com/ifauzeee/Zee-AI/internal/ollama"
+)
+
+func (h *Handler) ListTools(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"tools": h.tools.List(),
+	})
+}
+
+func (h *Handler) toolDefinitions(w http.ResponseWriter, names []string) ([]ollama.Tool, bool) {
+	if len(names) == 0 {
+		return nil, true
+	}
+	for _, name := range names {
+		if _, ok := h.tools.Get(name); !ok {
+			writeError(w, http.StatusBadRequest, "Unknown tool: "+name)
+			return nil, false
+		}
+	}
+	defs, err := h.tools.Definitions(names)
+	if err != nil {
+		h.logger.Error("build tool definitions failed", "error", err)
+		writeError(w, http.StatusInternalServerError, "Failed to load tools")
+		return nil, false
+	}
+	return defs, true
+}
+
+func toolCallNames(m db.Message) []string {
+	var names []string
+	for _, call := range chatMessage(m).ToolCalls {
+		names = append(names, call.Function.Name)
+	}
+	return names
+}
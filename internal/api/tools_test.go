@@ -0,0 +1,94 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ifauzeee/Zee-AI/internal/ollama"
+)
+
+func TestChatToolLoop(t *testing.T) {
+	var requests []ollama.ChatRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/chat" {
+			http.NotFound(w, r)
+			return
+		}
+		var req ollama.ChatRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		if !req.Stream {
+			fmt.Fprint(w, `{"message":{"role":"assistant","content":"Multiplication"},"done":true}`)
+			return
+		}
+		requests = append(requests, req)
+		if len(requests) == 1 {
+			fmt.Fprint(w, `{"message":{"role":"assistant","content":"","tool_calls":[{"function":{"name":"calculator","arguments":{"expression":"6 * 7"}}}]},"done":false}`+"\n")
+			fmt.Fprint(w, `{"message":{"role":"assistant","content":""},"done":true,"eval_count":4}`+"\n")
+			return
+		}
+		fmt.Fprint(w, `{"message":{"role":"assistant","content":"It is 42."},"done":false}`+"\n")
+		fmt.Fprint(w, `{"message":{"role":"assistant","content":""},"done":true,"eval_count":3}`+"\n")
+	}))
+	t.Cleanup(srv.Close)
+	router, database := newTestRouterWithOllama(t, srv.URL)
+	headers := map[string]string{"Authorization": "Bearer s3cret"}
+
+	rec := doRequest(t, router, http.MethodPost, "/api/chat", headers, map[string]interface{}{
+		"model":   "llama3",
+		"message": "what is 6 times 7?",
+		"tools":   []string{"nope"},
+	})
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "Unknown tool: nope") {
+		t.Fatalf("unknown tool = %d %s, want 400", rec.Code, rec.Body)
+	}
+
+	rec = doRequest(t, router, http.MethodPost, "/api/chat", headers, map[string]interface{}{
+		"model":   "llama3",
+		"message": "what is 6 times 7?",
+		"tools":   []string{"calculator"},
+	})
+	var types []string
+	var convID string
+	for _, line := range strings.Split(rec.Body.String(), "\n") {
+		data, ok := strings.CutPrefix(line, "data: ")
+		if !ok {
+			continue
+		}
+		var event map[string]interface{}
+		json.Unmarshal([]byte(data), &event)
+		types = append(types, event["type"].(string))
+		if event["type"] == "init" {
+			convID = event["conversation_id"].(string)
+		}
+		if event["type"] == "tool_result" && event["content"] != "42" {
+			t.Fatalf("tool result = %v", event)
+		}
+	}
+	if got := strings.Join(types, ","); got != "init,tool_call,tool_result,chunk,chunk" {
+		t.Fatalf("events = %s\n%s", got, rec.Body)
+	}
+
+	if len(requests) != 2 || len(requests[0].Tools) != 1 || requests[0].Tools[0].Function.Name != "calculator" {
+		t.Fatalf("requests = %+v", requests)
+	}
+	msgs := requests[1].Messages
+	if n := len(msgs); n != 3 || msgs[1].ToolCalls[0].Function.Name != "calculator" || msgs[2].Role != "tool" || msgs[2].ToolName != "calculator" || msgs[2].Content != "42" {
+		t.Fatalf("follow-up messages = %+v", msgs)
+	}
+
+	branch, err := database.GetActiveBranch(convID, "admin-id")
+	if err != nil {
+		t.Fatalf("get branch: %v", err)
+	}
+	var roles []string
+	for _, m := range branch {
+		roles = append(roles, m.Role)
+	}
+	if got := strings.Join(roles, ","); got != "user,assistant,tool,assistant" || branch[3].Content != "It is 42." || len(branch[1].ToolCalls) == 0 {
+		t.Fatalf("stored branch = %s", got)
+	}
+}
@@ -0,0 +1,33 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/ifauzeee/Zee-AI/internal/db"
+)
+
+func (h *Handler) RestoreConversation(w http.ResponseWriter, r *http.Request) {
+	if err := h.db.RestoreConversation(r.PathValue("id"), currentUser(r).ID); err != nil {
+		if errors.Is(err, db.ErrNotFound) {
+			writeError(w, http.StatusNotFound, "Conversation not found in trash")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "Failed to restore conversation")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"status": "restored"})
+}
+
+func (h *Handler) EmptyTrash(w http.ResponseWriter, r *http.Request) {
+	n, err := h.db.EmptyTrash(currentUser(r).ID)
+	if err != nil {
+		h.logger.Error("empty trash failed", "error", err)
+		writeError(w, http.StatusInternalServerError, "Failed to empty trash")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"status":  "emptied",
+		"deleted": n,
+	})
+}
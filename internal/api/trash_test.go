@@ -0,0 +1,79 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ifauzeee/Zee-AI/internal/db"
+)
+
+func TestTrashAndArchive(t *testing.T) {
+	router, database := newTestRouter(t)
+	headers := map[string]string{"X-API-Key": "s3cret"}
+
+	for _, id := range []string{"a", "b", "c", "d"} {
+		if _, err := database.CreateConversation(id, "admin-id", "Chat", "llama"); err != nil {
+			t.Fatalf("create conversation: %v", err)
+		}
+	}
+	list := func(view string) string {
+		t.Helper()
+		rec := doRequest(t, router, http.MethodGet, "/api/conversations?sort=created_asc&view="+view, headers, nil)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("list = %d: %s", rec.Code, rec.Body)
+		}
+		var resp struct {
+			Conversations []db.Conversation `json:"conversations"`
+		}
+		json.Unmarshal(rec.Body.Bytes(), &resp)
+		var ids []string
+		for _, c := range resp.Conversations {
+			ids = append(ids, c.ID)
+		}
+		return fmt.Sprint(ids)
+	}
+	expect := func(rec *httptest.ResponseRecorder, code int) {
+		t.Helper()
+		if rec.Code != code {
+			t.Fatalf("status = %d, want %d: %s", rec.Code, code, rec.Body)
+		}
+	}
+
+	expect(doRequest(t, router, http.MethodPatch, "/api/conversations/c", headers, map[string]bool{"pinned": true}), http.StatusOK)
+	expect(doRequest(t, router, http.MethodPatch, "/api/conversations/b", headers, map[string]bool{"archived": true}), http.StatusOK)
+	expect(doRequest(t, router, http.MethodDelete, "/api/conversations/d", headers, nil), http.StatusOK)
+
+	if got := list(""); got != "[c a]" {
+		t.Fatalf("default list = %s, want pinned first without archived or trashed", got)
+	}
+	if got := list("archived"); got != "[b]" {
+		t.Fatalf("archived list = %s", got)
+	}
+	if got := list("trash"); got != "[d]" {
+		t.Fatalf("trash list = %s", got)
+	}
+
+	expect(doRequest(t, router, http.MethodPost, "/api/conversations/d/restore", headers, nil), http.StatusOK)
+	expect(doRequest(t, router, http.MethodPost, "/api/conversations/d/restore", headers, nil), http.StatusNotFound)
+	if got := list("all"); got != "[c a b d]" {
+		t.Fatalf("all list = %s", got)
+	}
+
+	expect(doRequest(t, router, http.MethodDelete, "/api/conversations/a", headers, nil), http.StatusOK)
+	expect(doRequest(t, router, http.MethodDelete, "/api/trash", headers, nil), http.StatusOK)
+	if _, err := database.GetConversation("a", "admin-id"); err == nil {
+		t.Fatal("conversation survived emptying the trash")
+	}
+
+	expect(doRequest(t, router, http.MethodDelete, "/api/conversations/b", headers, nil), http.StatusOK)
+	if n, err := database.PurgeTrash(time.Now().Add(-time.Hour)); err != nil || n != 0 {
+		t.Fatalf("purge before retention = %d, %v", n, err)
+	}
+	if n, err := database.PurgeTrash(time.Now().Add(time.Second)); err != nil || n != 1 {
+		t.Fatalf("purge after retention = %d, %v", n, err)
+	}
+}
@@ -0,0 +1,161 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/ifauzeee/Zee-AI/internal/auth"
+	"github.com/ifauzeee/Zee-AI/internal/db"
+)
+
+const minPasswordLength = 8
+
+func (h *Handler) ListUsers(w http.ResponseWriter, r *http.Request) {
+	users, err := h.db.ListUsers()
+	if err != nil {
+		h.logger.Error("list users failed", "error", err)
+		writeError(w, http.StatusInternalServerError, "Failed to list users")
+		return
+	}
+	if users == nil {
+		users = []db.User{}
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"users": users,
+	})
+}
+
+func (h *Handler) CreateUser(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Username string  `json:"username"`
+		Password string  `json:"password"`
+		Role     db.Role `json:"role"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	req.Username = strings.TrimSpace(req.Username)
+	if req.Username == "" {
+		writeError(w, http.StatusBadRequest, "Username is required")
+		return
+	}
+	if len(req.Password) < minPasswordLength {
+		writeError(w, http.StatusBadRequest, "Password is too short")
+		return
+	}
+	if req.Role == "" {
+		req.Role = db.RoleMember
+	}
+	if !req.Role.Valid() {
+		writeError(w, http.StatusBadRequest, "Role must be one of admin, member, readonly")
+		return
+	}
+
+	if _, err := h.db.GetUserByUsername(req.Username); err == nil {
+		writeError(w, http.StatusConflict, "Username already exists")
+		return
+	}
+
+	hash, err := auth.HashPassword(req.Password)
+	if err != nil {
+		h.logger.Error("hash password failed", "error", err)
+		writeError(w, http.StatusInternalServerError, "Failed to create user")
+		return
+	}
+	user := &db.User{
+		ID:           uuid.New().String(),
+		Username:     req.Username,
+		PasswordHash: hash,
+		Role:         req.Role,
+	}
+	if err := h.db.CreateUser(user); err != nil {
+		h.logger.Error("create user failed", "error", err)
+		writeError(w, http.StatusInternalServerError, "Failed to create user")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, user)
+}
+
+func (h *Handler) UpdateUser(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	var req struct {
+		Role db.Role `json:"role"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if !req.Role.Valid() {
+		writeError(w, http.StatusBadRequest, "Role must be one of admin, member, readonly")
+		return
+	}
+	if id == currentUser(r).ID && req.Role != db.RoleAdmin {
+		writeError(w, http.StatusBadRequest, "Cannot remove your own admin role")
+		return
+	}
+	if req.Role != db.RoleAdmin && h.isKeyAdmin(id) {
+		writeError(w, http.StatusBadRequest, "Cannot remove the admin role from the API_SECRET_KEY account")
+		return
+	}
+
+	if err := h.db.UpdateUserRole(id, req.Role); err != nil {
+		if errors.Is(err, db.ErrNotFound) {
+			writeError(w, http.StatusNotFound, "User not found")
+			return
+		}
+		if errors.Is(err, db.ErrLastAdmin) {
+			writeError(w, http.StatusBadRequest, "Cannot remove the last admin")
+			return
+		}
+		h.logger.Error("update user role failed", "error", err)
+		writeError(w, http.StatusInternalServerError, "Failed to update user")
+		return
+	}
+
+	user, err := h.db.GetUser(id)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to update user")
+		return
+	}
+	writeJSON(w, http.StatusOK, user)
+}
+
+func (h *Handler) DeleteUser(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if id == currentUser(r).ID {
+		writeError(w, http.StatusBadRequest, "Cannot delete your own account")
+		return
+	}
+	if h.isKeyAdmin(id) {
+		writeError(w, http.StatusBadRequest, "Cannot delete the API_SECRET_KEY account")
+		return
+	}
+
+	if err := h.db.DeleteUser(id); err != nil {
+		if errors.Is(err, db.ErrNotFound) {
+			writeError(w, http.StatusNotFound, "User not found")
+			return
+		}
+		if errors.Is(err, db.ErrLastAdmin) {
+			writeError(w, http.StatusBadRequest, "Cannot delete the last admin")
+			return
+		}
+		h.logger.Error("delete user failed", "error", err)
+		writeError(w, http.StatusInternalServerError, "Failed to delete user")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"status": "deleted"})
+}
+
+func (h *Handler) isKeyAdmin(id string) bool {
+	if h.cfg.APISecretKey == "" {
+		return false
+	}
+	user, err := h.db.GetUser(id)
+	return err == nil && user.Username == h.cfg.AdminUsername
+}
@@ -0,0 +1,64 @@
+package auth
+
+import (
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	passwordIterations = 600000
+	passwordSaltLen    = 16
+	passwordKeyLen     = 32
+	passwordScheme     = "pbkdf2-sha256"
+)
+
+var ErrInvalidHash = errors.New("invalid password hash")
+
+func HashPassword(password string) (string, error) {
+	salt := make([]byte, passwordSaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return "", fmt.Errorf("generate salt: %w", err)
+	}
+	key, err := pbkdf2.Key(sha256.New, password, salt, passwordIterations, passwordKeyLen)
+	if err != nil {
+		return "", fmt.Errorf("derive key: %w", err)
+	}
+	return fmt.Sprintf("%s$%d$%s$%s",
+		passwordScheme,
+		passwordIterations,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+func CheckPassword(hash, password string) (bool, error) {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 4 || parts[0] != passwordScheme {
+		return false, ErrInvalidHash
+	}
+	iterations, err := strconv.Atoi(parts[1])
+	if err != nil || iterations <= 0 {
+		return false, ErrInvalidHash
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return false, ErrInvalidHash
+	}
+	want, err := base64.RawStdEncoding.DecodeString(parts[3])
+	if err != nil {
+		return false, ErrInvalidHash
+	}
+
+	got, err := pbkdf2.Key(sha256.New, password, salt, iterations, len(want))
+	if err != nil {
+		return false, fmt.Errorf("derive key: %w", err)
+	}
+	return subtle.ConstantTimeCompare(got, want) == 1, nil
+}
@@ -2,7 +2,9 @@ package config
 
 import (
 	"os"
+	"strconv"
 	"strings"
+	"time"
 )
 
 type Config struct {
@@ -12,6 +14,33 @@ type Config struct {
 	DBPath        string
 	FrontendURL   string
 	APISecretKey  string
+	AdminUsername string
+	AdminPassword string
+	SessionTTL    time.Duration
+
+	OllamaBalance        string
+	OllamaHealthInterval time.Duration
+
+	ContextStrategy string
+	ContextWindow   int
+	ContextReserve  int
+	SummaryInterval int
+
+	TrashRetention  time.Duration
+	JanitorInterval time.Duration
+
+	OpenAILogConversations bool
+
+	MaxAttachments  int
+	MaxAttachmentMB int
+
+	Providers []ProviderConfig
+}
+
+type ProviderConfig struct {
+	Name    string
+	BaseURL string
+	APIKey  string
 }
 
 func Load() *Config {
@@ -22,6 +51,27 @@ func Load() *Config {
 		DBPath:        getEnv("DB_PATH", "./zee-ai.db"),
 		FrontendURL:   getEnv("FRONTEND_URL", "http://localhost:3000"),
 		APISecretKey:  getEnv("API_SECRET_KEY", ""),
+		AdminUsername: getEnv("ADMIN_USERNAME", "admin"),
+		AdminPassword: getEnv("ADMIN_PASSWORD", ""),
+		SessionTTL:    getDuration("SESSION_TTL", 7*24*time.Hour),
+
+		OllamaBalance:        getEnv("OLLAMA_BALANCE", "least_busy"),
+		OllamaHealthInterval: getDuration("OLLAMA_HEALTH_INTERVAL", 30*time.Second),
+
+		ContextStrategy: getEnv("CONTEXT_STRATEGY", "system_recent"),
+		ContextWindow:   getInt("CONTEXT_WINDOW", 4096),
+		ContextReserve:  getInt("CONTEXT_RESERVE", 512),
+		SummaryInterval: getInt("SUMMARY_INTERVAL", 6),
+
+		TrashRetention:  getDuration("TRASH_RETENTION", 30*24*time.Hour),
+		JanitorInterval: getDuration("JANITOR_INTERVAL", time.Hour),
+
+		OpenAILogConversations: getBool("OPENAI_LOG_CONVERSATIONS", false),
+
+		MaxAttachments:  getInt("ATTACHMENT_MAX_COUNT", 4),
+		MaxAttachmentMB: getInt("ATTACHMENT_MAX_MB", 10),
+
+		Providers: getProviders("PROVIDERS"),
 	}
 }
 
@@ -39,9 +89,64 @@ func (c *Config) CORSOrigins() []string {
 	return origins
 }
 
+func (c *Config) OllamaHosts() []string {
+	var hosts []string
+	for _, h := range strings.Split(c.OllamaBaseURL, ",") {
+		if h = strings.TrimRight(strings.TrimSpace(h), "/"); h != "" {
+			hosts = append(hosts, h)
+		}
+	}
+	return hosts
+}
+
 func getEnv(key, fallback string) string {
 	if val := os.Getenv(key); val != "" {
 		return val
 	}
 	return fallback
 }
+
+func getDuration(key string, fallback time.Duration) time.Duration {
+	if val := os.Getenv(key); val != "" {
+		if d, err := time.ParseDuration(val); err == nil && d > 0 {
+			return d
+		}
+	}
+	return fallback
+}
+
+func getProviders(key string) []ProviderConfig {
+	var providers []ProviderConfig
+	for _, entry := range strings.Split(getEnv(key, ""), ",") {
+		name, url, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		name, url = strings.TrimSpace(name), strings.TrimSpace(url)
+		if !ok || name == "" || url == "" || strings.ContainsAny(name, "/:") {
+			continue
+		}
+		envName := strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+		providers = append(providers, ProviderConfig{
+			Name:    name,
+			BaseURL: url,
+			APIKey:  getEnv("PROVIDER_"+envName+"_API_KEY", ""),
+		})
+	}
+	return providers
+}
+
+func getBool(key string, fallback bool) bool {
+	if val := os.Getenv(key); val != "" {
+		if b, err := strconv.ParseBool(val); err == nil {
+			return b
+		}
+	}
+	return fallback
+}
+
+func getInt(key string, fallback int) int {
+	if val := os.Getenv(key); val != "" {
+		if n, err := strconv.Atoi(val); err == nil && n >= 0 {
+			return n
+		}
+	}
+	return fallback
+}
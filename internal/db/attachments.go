@@ -0,0 +1,114 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+)
+
+type Attachment struct {
+	ID             string    `json:"id"`
+	MessageID      string    `json:"message_id"`
+	ConversationID string    `json:"conversation_id"`
+	UserID         string    `json:"-"`
+	Filename       string    `json:"filename"`
+	MimeType       string    `json:"mime_type"`
+	Size           int64     `json:"size"`
+	Data           []byte    `json:"-"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+const attachmentColumns = "a.id, a.message_id, a.conversation_id, a.user_id, a.filename, a.mime_type, a.size, a.created_at"
+
+func scanAttachment(row interface{ Scan(...any) error }) (*Attachment, error) {
+	a := &Attachment{}
+	err := row.Scan(&a.ID, &a.MessageID, &a.ConversationID, &a.UserID, &a.Filename, &a.MimeType, &a.Size, &a.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+func insertAttachments(tx *sql.Tx, msg *Message) error {
+	for i := range msg.Attachments {
+		a := &msg.Attachments[i]
+		a.MessageID = msg.ID
+		a.ConversationID = msg.ConversationID
+		a.Size = int64(len(a.Data))
+		if a.CreatedAt.IsZero() {
+			a.CreatedAt = msg.CreatedAt
+		}
+		_, err := tx.Exec(
+			"INSERT INTO attachments (id, message_id, conversation_id, user_id, filename, mime_type, size, data, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)",
+			a.ID, a.MessageID, a.ConversationID, a.UserID, a.Filename, a.MimeType, a.Size, a.Data, a.CreatedAt,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *DB) GetAttachment(id, userID string) (*Attachment, error) {
+	row := d.conn.QueryRow(
+		"SELECT "+attachmentColumns+", a.data FROM attachments a JOIN conversations c ON c.id = a.conversation_id"+
+			" WHERE a.id = ? AND c.user_id = ? AND c.deleted_at IS NULL",
+		id, userID,
+	)
+	var data []byte
+	a, err := scanAttachment(scanFunc(func(dest ...any) error {
+		return row.Scan(append(dest, &data)...)
+	}))
+	if err != nil {
+		return nil, err
+	}
+	a.Data = data
+	return a, nil
+}
+
+func (d *DB) LoadAttachments(msgs []Message, withData bool) error {
+	if len(msgs) == 0 {
+		return nil
+	}
+	index := make(map[string]int, len(msgs))
+	args := make([]interface{}, len(msgs))
+	for i := range msgs {
+		index[msgs[i].ID] = i
+		args[i] = msgs[i].ID
+	}
+
+	columns := attachmentColumns
+	if withData {
+		columns += ", a.data"
+	}
+	rows, err := d.conn.Query(
+		"SELECT "+columns+" FROM attachments a WHERE a.message_id IN (?"+strings.Repeat(", ?", len(args)-1)+") ORDER BY a.created_at, a.rowid",
+		args...,
+	)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var data []byte
+		a, err := scanAttachment(scanFunc(func(dest ...any) error {
+			if withData {
+				dest = append(dest, &data)
+			}
+			return rows.Scan(dest...)
+		}))
+		if err != nil {
+			return err
+		}
+		a.Data = data
+		if i, ok := index[a.MessageID]; ok {
+			msgs[i].Attachments = append(msgs[i].Attachments, *a)
+		}
+	}
+	return rows.Err()
+}
@@ -0,0 +1,130 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+)
+
+type Branch struct {
+	Leaf     Message `json:"leaf"`
+	Length   int     `json:"length"`
+	Preview  string  `json:"preview"`
+	IsActive bool    `json:"is_active"`
+}
+
+func (d *DB) GetBranch(conversationID, userID, leafID string) ([]Message, error) {
+	if leafID == "" {
+		return nil, nil
+	}
+	rows, err := d.conn.Query(`
+		WITH RECURSIVE branch(id, depth) AS (
+			SELECT id, 0 FROM messages WHERE id = ? AND conversation_id = ?
+			UNION ALL
+			SELECT p.parent_id, b.depth + 1
+			FROM branch b
+			JOIN messages p ON p.id = b.id
+			WHERE p.parent_id IS NOT NULL
+		)
+		SELECT `+messageColumns+`
+		FROM branch b
+		JOIN messages m ON m.id = b.id
+		JOIN conversations c ON c.id = m.conversation_id
+		WHERE c.user_id = ?
+		ORDER BY b.depth DESC`,
+		leafID, conversationID, userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return scanMessages(rows)
+}
+
+func (d *DB) GetActiveBranch(conversationID, userID string) ([]Message, error) {
+	convo, err := d.GetConversation(conversationID, userID)
+	if err != nil {
+		return nil, err
+	}
+	return d.GetBranch(conversationID, userID, convo.ActiveMessageID)
+}
+
+func (d *DB) LatestLeaf(conversationID, messageID string) (string, error) {
+	current := messageID
+	for {
+		var child string
+		err := d.conn.QueryRow(
+			"SELECT id FROM messages WHERE conversation_id = ? AND parent_id = ? ORDER BY created_at DESC, rowid DESC LIMIT 1",
+			conversationID, current,
+		).Scan(&child)
+		if errors.Is(err, sql.ErrNoRows) {
+			return current, nil
+		}
+		if err != nil {
+			return "", err
+		}
+		current = child
+	}
+}
+
+func (d *DB) SetActiveMessage(conversationID, userID, messageID string) error {
+	res, err := d.conn.Exec(
+		"UPDATE conversations SET active_message_id = ? WHERE id = ? AND user_id = ?",
+		messageID, conversationID, userID,
+	)
+	if err != nil {
+		return err
+	}
+	return expectAffected(res)
+}
+
+func (d *DB) ListBranches(conversationID, userID string) ([]Branch, error) {
+	convo, err := d.GetConversation(conversationID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := d.conn.Query(`
+		SELECT `+messageColumns+`
+		FROM messages m
+		JOIN conversations c ON c.id = m.conversation_id
+		WHERE m.conversation_id = ? AND c.user_id = ?
+			AND NOT EXISTS (SELECT 1 FROM messages child WHERE child.parent_id = m.id)
+		ORDER BY m.created_at ASC`,
+		conversationID, userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	leaves, err := scanMessages(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	branches := make([]Branch, 0, len(leaves))
+	for _, leaf := range leaves {
+		path, err := d.GetBranch(conversationID, userID, leaf.ID)
+		if err != nil {
+			return nil, err
+		}
+		b := Branch{
+			Leaf:     leaf,
+			Length:   len(path),
+			IsActive: leaf.ID == convo.ActiveMessageID,
+		}
+		for i := len(path) - 1; i >= 0; i-- {
+			if path[i].Role == "user" {
+				b.Preview = truncate(path[i].Content, 120)
+				break
+			}
+		}
+		branches = append(branches, b)
+	}
+	return branches, nil
+}
+
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n]) + "…"
+}
@@ -0,0 +1,96 @@
+package db
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func messageIDs(msgs []Message) []string {
+	ids := make([]string, len(msgs))
+	for i, m := range msgs {
+		ids[i] = m.ID
+	}
+	return ids
+}
+
+func equalIDs(got []string, want ...string) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestBranches(t *testing.T) {
+	d := newTestDB(t)
+	start := time.Date(2026, 2, 1, 9, 0, 0, 0, time.UTC)
+	mustConversation(t, d, "c1", "u1", "Tree", "llama3",
+		Message{ID: "q1", Role: "user", Content: "first question", CreatedAt: start},
+		Message{ID: "a1", ParentID: "q1", Role: "assistant", Content: "first answer", CreatedAt: start.Add(time.Minute)},
+		Message{ID: "q2", ParentID: "a1", Role: "user", Content: "follow-up", CreatedAt: start.Add(2 * time.Minute)},
+		Message{ID: "a2", ParentID: "q2", Role: "assistant", Content: "answer one", CreatedAt: start.Add(3 * time.Minute)},
+		Message{ID: "a2b", ParentID: "q2", Role: "assistant", Content: "answer two", CreatedAt: start.Add(4 * time.Minute)},
+		Message{ID: "q2e", ParentID: "a1", Role: "user", Content: "edited follow-up", CreatedAt: start.Add(5 * time.Minute)},
+	)
+	mustConversation(t, d, "c2", "u1", "Other", "llama3",
+		Message{ID: "x1", Role: "user", Content: "elsewhere"},
+	)
+
+	branch, err := d.GetBranch("c1", "u1", "a2")
+	if err != nil || !equalIDs(messageIDs(branch), "q1", "a1", "q2", "a2") {
+		t.Fatalf("GetBranch(a2) = %v, %v", messageIDs(branch), err)
+	}
+	if branch, _ := d.GetBranch("c1", "u2", "a2"); len(branch) != 0 {
+		t.Fatalf("GetBranch for another user = %v", messageIDs(branch))
+	}
+	if branch, _ := d.GetBranch("c1", "u1", "x1"); len(branch) != 0 {
+		t.Fatalf("GetBranch across conversations = %v", messageIDs(branch))
+	}
+	if branch, err := d.GetBranch("c1", "u1", ""); err != nil || branch != nil {
+		t.Fatalf("GetBranch(\"\") = %v, %v", branch, err)
+	}
+
+	active, _ := d.GetActiveBranch("c1", "u1")
+	if !equalIDs(messageIDs(active), "q1", "a1", "q2e") {
+		t.Fatalf("active branch = %v", messageIDs(active))
+	}
+
+	for from, want := range map[string]string{"q1": "q2e", "q2": "a2b", "a2": "a2"} {
+		if leaf, err := d.LatestLeaf("c1", from); err != nil || leaf != want {
+			t.Errorf("LatestLeaf(%s) = %s, %v, want %s", from, leaf, err, want)
+		}
+	}
+
+	if err := d.SetActiveMessage("c1", "u2", "a2"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("SetActiveMessage for another user = %v", err)
+	}
+	if err := d.SetActiveMessage("c1", "u1", "a2"); err != nil {
+		t.Fatal(err)
+	}
+
+	branches, err := d.ListBranches("c1", "u1")
+	if err != nil || len(branches) != 3 {
+		t.Fatalf("ListBranches = %+v, %v", branches, err)
+	}
+	var leaves []string
+	for _, b := range branches {
+		leaves = append(leaves, b.Leaf.ID)
+		if b.IsActive != (b.Leaf.ID == "a2") {
+			t.Errorf("branch %s active = %v", b.Leaf.ID, b.IsActive)
+		}
+	}
+	if !equalIDs(leaves, "a2", "a2b", "q2e") {
+		t.Fatalf("branch leaves = %v", leaves)
+	}
+	if branches[0].Length != 4 || branches[0].Preview != "follow-up" || branches[2].Length != 3 || branches[2].Preview != "edited follow-up" {
+		t.Fatalf("branches = %+v", branches)
+	}
+	if _, err := d.ListBranches("c1", "u2"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("ListBranches for another user = %v", err)
+	}
+}
@@ -2,33 +2,54 @@ package db
 
 import (
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 
 	_ "modernc.org/sqlite"
 )
 
+var ErrNotFound = errors.New("not found")
+
 type DB struct {
 	conn *sql.DB
 }
 
 type Conversation struct {
-	ID        string    `json:"id"`
-	Title     string    `json:"title"`
-	Model     string    `json:"model"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID               string     `json:"id"`
+	UserID           string     `json:"user_id"`
+	Title            string     `json:"title"`
+	Model            string     `json:"model"`
+	ActiveMessageID  string     `json:"active_message_id,omitempty"`
+	Summary          string     `json:"summary,omitempty"`
+	SummaryMessageID string     `json:"summary_message_id,omitempty"`
+	SummaryUpdatedAt *time.Time `json:"summary_updated_at,omitempty"`
+	FolderID         string     `json:"folder_id,omitempty"`
+	Tags             []Tag      `json:"tags,omitempty"`
+	Pinned           bool       `json:"pinned"`
+	ArchivedAt       *time.Time `json:"archived_at,omitempty"`
+	DeletedAt        *time.Time `json:"deleted_at,omitempty"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
 }
 
 type Message struct {
-	ID             string    `json:"id"`
-	ConversationID string    `json:"conversation_id"`
-	Role           string    `json:"role"`
-	Content        string    `json:"content"`
-	Model          string    `json:"model,omitempty"`
-	TokensUsed     int       `json:"tokens_used,omitempty"`
-	Duration       float64   `json:"duration,omitempty"`
-	CreatedAt      time.Time `json:"created_at"`
+	ID              string          `json:"id"`
+	ConversationID  string          `json:"conversation_id"`
+	ParentID        string          `json:"parent_id,omitempty"`
+	Role            string          `json:"role"`
+	Content         string          `json:"content"`
+	Model           string          `json:"model,omitempty"`
+	TokensUsed      int             `json:"tokens_used,omitempty"`
+	Duration        float64         `json:"duration,omitempty"`
+	Interrupted     bool            `json:"interrupted,omitempty"`
+	ToolCalls       json.RawMessage `json:"tool_calls,omitempty"`
+	ToolName        string          `json:"tool_name,omitempty"`
+	SettingsVersion int             `json:"settings_version,omitempty"`
+	Options         json.RawMessage `json:"options,omitempty"`
+	Attachments     []Attachment    `json:"attachments,omitempty"`
+	CreatedAt       time.Time       `json:"created_at"`
 }
 
 func New(dbPath string) (*DB, error) {
@@ -41,6 +62,7 @@ func New(dbPath string) (*DB, error) {
 	conn.SetMaxIdleConns(1)
 
 	if err := migrate(conn); err != nil {
+		conn.Close()
 		return nil, fmt.Errorf("migrate: %w", err)
 	}
 
@@ -72,37 +94,383 @@ func migrate(conn *sql.DB) error {
 		CREATE INDEX IF NOT EXISTS idx_messages_conversation_id ON messages(conversation_id);
 		CREATE INDEX IF NOT EXISTS idx_conversations_updated ON conversations(updated_at DESC);
 	`)
-	return err
+	if err != nil {
+		return err
+	}
+
+	var version int
+	if err := conn.QueryRow("PRAGMA user_version").Scan(&version); err != nil {
+		return fmt.Errorf("read schema version: %w", err)
+	}
+
+	for i := version; i < len(migrations); i++ {
+		tx, err := conn.Begin()
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(migrations[i]); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("migration %d: %w", i+1, err)
+		}
+		if _, err := tx.Exec(fmt.Sprintf("PRAGMA user_version = %d", i+1)); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("migration %d: %w", i+1, err)
+		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("migration %d: %w", i+1, err)
+		}
+	}
+	return nil
+}
+
+var migrations = []string{
+	`
+	CREATE TABLE users (
+		id TEXT PRIMARY KEY,
+		username TEXT NOT NULL UNIQUE COLLATE NOCASE,
+		password_hash TEXT NOT NULL,
+		is_admin INTEGER NOT NULL DEFAULT 0,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE sessions (
+		token_hash TEXT PRIMARY KEY,
+		user_id TEXT NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		expires_at DATETIME NOT NULL,
+		FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+	);
+
+	ALTER TABLE conversations ADD COLUMN user_id TEXT NOT NULL DEFAULT '';
+
+	CREATE INDEX idx_sessions_user_id ON sessions(user_id);
+	CREATE INDEX idx_conversations_user_updated ON conversations(user_id, updated_at DESC);
+	`,
+	`
+	ALTER TABLE users ADD COLUMN role TEXT NOT NULL DEFAULT 'member' CHECK(role IN ('admin', 'member', 'readonly'));
+	UPDATE users SET role = 'admin' WHERE is_admin = 1;
+	ALTER TABLE users DROP COLUMN is_admin;
+	`,
+	`
+	CREATE TABLE api_tokens (
+		id TEXT PRIMARY KEY,
+		user_id TEXT NOT NULL,
+		name TEXT NOT NULL,
+		token_hash TEXT NOT NULL UNIQUE,
+		prefix TEXT NOT NULL,
+		scopes TEXT NOT NULL DEFAULT '',
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		last_used_at DATETIME,
+		expires_at DATETIME,
+		revoked_at DATETIME,
+		FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+	);
+
+	CREATE INDEX idx_api_tokens_user_id ON api_tokens(user_id);
+	`,
+	`
+	ALTER TABLE messages ADD COLUMN interrupted INTEGER NOT NULL DEFAULT 0;
+	`,
+	`
+	ALTER TABLE messages ADD COLUMN parent_id TEXT;
+	ALTER TABLE conversations ADD COLUMN active_message_id TEXT;
+
+	UPDATE messages SET parent_id = (
+		SELECT p.id FROM messages p
+		WHERE p.conversation_id = messages.conversation_id
+			AND (p.created_at < messages.created_at OR (p.created_at = messages.created_at AND p.rowid < messages.rowid))
+		ORDER BY p.created_at DESC, p.rowid DESC
+		LIMIT 1
+	);
+
+	UPDATE conversations SET active_message_id = (
+		SELECT m.id FROM messages m
+		WHERE m.conversation_id = conversations.id
+		ORDER BY m.created_at DESC, m.rowid DESC
+		LIMIT 1
+	);
+
+	CREATE INDEX idx_messages_parent_id ON messages(parent_id);
+	`,
+	`
+	ALTER TABLE conversations ADD COLUMN summary TEXT NOT NULL DEFAULT '';
+	ALTER TABLE conversations ADD COLUMN summary_message_id TEXT;
+	ALTER TABLE conversations ADD COLUMN summary_updated_at DATETIME;
+	`,
+	`
+	CREATE VIRTUAL TABLE messages_fts USING fts5(
+		content,
+		content='messages',
+		content_rowid='rowid',
+		tokenize='unicode61 remove_diacritics 2'
+	);
+
+	CREATE VIRTUAL TABLE conversations_fts USING fts5(
+		title,
+		content='conversations',
+		content_rowid='rowid',
+		tokenize='unicode61 remove_diacritics 2'
+	);
+
+	CREATE TRIGGER messages_fts_insert AFTER INSERT ON messages BEGIN
+		INSERT INTO messages_fts(rowid, content) VALUES (new.rowid, new.content);
+	END;
+	CREATE TRIGGER messages_fts_delete AFTER DELETE ON messages BEGIN
+		INSERT INTO messages_fts(messages_fts, rowid, content) VALUES ('delete', old.rowid, old.content);
+	END;
+	CREATE TRIGGER messages_fts_update AFTER UPDATE OF content ON messages BEGIN
+		INSERT INTO messages_fts(messages_fts, rowid, content) VALUES ('delete', old.rowid, old.content);
+		INSERT INTO messages_fts(rowid, content) VALUES (new.rowid, new.content);
+	END;
+
+	CREATE TRIGGER conversations_fts_insert AFTER INSERT ON conversations BEGIN
+		INSERT INTO conversations_fts(rowid, title) VALUES (new.rowid, new.title);
+	END;
+	CREATE TRIGGER conversations_fts_delete AFTER DELETE ON conversations BEGIN
+		INSERT INTO conversations_fts(conversations_fts, rowid, title) VALUES ('delete', old.rowid, old.title);
+	END;
+	CREATE TRIGGER conversations_fts_update AFTER UPDATE OF title ON conversations BEGIN
+		INSERT INTO conversations_fts(conversations_fts, rowid, title) VALUES ('delete', old.rowid, old.title);
+		INSERT INTO conversations_fts(rowid, title) VALUES (new.rowid, new.title);
+	END;
+
+	INSERT INTO messages_fts(messages_fts) VALUES ('rebuild');
+	INSERT INTO conversations_fts(conversations_fts) VALUES ('rebuild');
+	`,
+	`
+	CREATE TABLE folders (
+		id TEXT PRIMARY KEY,
+		user_id TEXT NOT NULL,
+		parent_id TEXT,
+		name TEXT NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+	CREATE INDEX idx_folders_user_id ON folders(user_id);
+
+	CREATE TABLE tags (
+		id TEXT PRIMARY KEY,
+		user_id TEXT NOT NULL,
+		name TEXT NOT NULL COLLATE NOCASE,
+		color TEXT NOT NULL DEFAULT '',
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE (user_id, name)
+	);
+
+	CREATE TABLE conversation_tags (
+		conversation_id TEXT NOT NULL,
+		tag_id TEXT NOT NULL,
+		PRIMARY KEY (conversation_id, tag_id)
+	);
+	CREATE INDEX idx_conversation_tags_tag_id ON conversation_tags(tag_id);
+
+	ALTER TABLE conversations ADD COLUMN folder_id TEXT;
+	CREATE INDEX idx_conversations_folder_id ON conversations(folder_id);
+	`,
+	`
+	ALTER TABLE conversations ADD COLUMN pinned INTEGER NOT NULL DEFAULT 0;
+	ALTER TABLE conversations ADD COLUMN archived_at DATETIME;
+	ALTER TABLE conversations ADD COLUMN deleted_at DATETIME;
+	CREATE INDEX idx_conversations_deleted_at ON conversations(deleted_at);
+	`,
+	`
+	CREATE TABLE conversation_imports (
+		user_id TEXT NOT NULL,
+		source TEXT NOT NULL,
+		external_id TEXT NOT NULL,
+		conversation_id TEXT NOT NULL,
+		imported_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (user_id, source, external_id)
+	);
+	CREATE INDEX idx_conversation_imports_conversation_id ON conversation_imports(conversation_id);
+	`,
+	`
+	CREATE TABLE shares (
+		id TEXT PRIMARY KEY,
+		user_id TEXT NOT NULL,
+		conversation_id TEXT NOT NULL,
+		token_hash TEXT NOT NULL UNIQUE,
+		prefix TEXT NOT NULL,
+		include_system INTEGER NOT NULL DEFAULT 0,
+		snapshot TEXT NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		expires_at DATETIME,
+		revoked_at DATETIME
+	);
+	CREATE INDEX idx_shares_conversation_id ON shares(conversation_id);
+	`,
+	`
+	CREATE TABLE messages_new (
+		id TEXT PRIMARY KEY,
+		conversation_id TEXT NOT NULL,
+		parent_id TEXT,
+		role TEXT NOT NULL CHECK(role IN ('user', 'assistant', 'system', 'tool')),
+		content TEXT NOT NULL,
+		model TEXT DEFAULT '',
+		tokens_used INTEGER DEFAULT 0,
+		duration REAL DEFAULT 0,
+		interrupted INTEGER NOT NULL DEFAULT 0,
+		tool_calls TEXT NOT NULL DEFAULT '',
+		tool_name TEXT NOT NULL DEFAULT '',
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (conversation_id) REFERENCES conversations(id) ON DELETE CASCADE
+	);
+
+	INSERT INTO messages_new (rowid, id, conversation_id, parent_id, role, content, model, tokens_used, duration, interrupted, created_at)
+	SELECT rowid, id, conversation_id, parent_id, role, content, model, tokens_used, duration, interrupted, created_at FROM messages;
+
+	DROP TABLE messages;
+	ALTER TABLE messages_new RENAME TO messages;
+
+	CREATE INDEX idx_messages_conversation_id ON messages(conversation_id);
+	CREATE INDEX idx_messages_parent_id ON messages(parent_id);
+
+	CREATE TRIGGER messages_fts_insert AFTER INSERT ON messages BEGIN
+		INSERT INTO messages_fts(rowid, content) VALUES (new.rowid, new.content);
+	END;
+	CREATE TRIGGER messages_fts_delete AFTER DELETE ON messages BEGIN
+		INSERT INTO messages_fts(messages_fts, rowid, content) VALUES ('delete', old.rowid, old.content);
+	END;
+	CREATE TRIGGER messages_fts_update AFTER UPDATE OF content ON messages BEGIN
+		INSERT INTO messages_fts(messages_fts, rowid, content) VALUES ('delete', old.rowid, old.content);
+		INSERT INTO messages_fts(rowid, content) VALUES (new.rowid, new.content);
+	END;
+
+	INSERT INTO messages_fts(messages_fts) VALUES ('rebuild');
+	`,
+	`
+	CREATE TABLE attachments (
+		id TEXT PRIMARY KEY,
+		message_id TEXT NOT NULL,
+		conversation_id TEXT NOT NULL,
+		user_id TEXT NOT NULL,
+		filename TEXT NOT NULL DEFAULT '',
+		mime_type TEXT NOT NULL,
+		size INTEGER NOT NULL,
+		data BLOB NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+	CREATE INDEX idx_attachments_message_id ON attachments(message_id);
+	CREATE INDEX idx_attachments_conversation_id ON attachments(conversation_id);
+	`,
+	`
+	CREATE TABLE conversation_settings (
+		conversation_id TEXT NOT NULL,
+		version INTEGER NOT NULL,
+		system_prompt TEXT NOT NULL DEFAULT '',
+		model TEXT NOT NULL DEFAULT '',
+		options TEXT NOT NULL DEFAULT '',
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (conversation_id, version)
+	);
+	ALTER TABLE messages ADD COLUMN settings_version INTEGER NOT NULL DEFAULT 0;
+	`,
+	`
+	ALTER TABLE messages ADD COLUMN options TEXT NOT NULL DEFAULT '';
+	`,
 }
 
 func (d *DB) Close() error {
 	return d.conn.Close()
 }
 
-func (d *DB) CreateConversation(id, title, model string) (*Conversation, error) {
+const conversationColumns = "c.id, c.user_id, c.title, c.model, COALESCE(c.active_message_id, ''), c.summary, COALESCE(c.summary_message_id, ''), c.summary_updated_at, COALESCE(c.folder_id, ''), c.pinned, c.archived_at, c.deleted_at, c.created_at, c.updated_at"
+
+func scanConversation(row interface{ Scan(...any) error }) (*Conversation, error) {
+	c := &Conversation{}
+	var summaryUpdated, archived, deleted sql.NullTime
+	err := row.Scan(&c.ID, &c.UserID, &c.Title, &c.Model, &c.ActiveMessageID, &c.Summary, &c.SummaryMessageID, &summaryUpdated,
+		&c.FolderID, &c.Pinned, &archived, &deleted, &c.CreatedAt, &c.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	c.SummaryUpdatedAt = nullTimePtr(summaryUpdated)
+	c.ArchivedAt = nullTimePtr(archived)
+	c.DeletedAt = nullTimePtr(deleted)
+	return c, nil
+}
+
+const messageColumns = "m.id, m.conversation_id, COALESCE(m.parent_id, ''), m.role, m.content, m.model, m.tokens_used, m.duration, m.interrupted, m.tool_calls, m.tool_name, m.settings_version, m.options, m.created_at"
+
+func scanMessage(row interface{ Scan(...any) error }) (*Message, error) {
+	m := &Message{}
+	var toolCalls, options string
+	err := row.Scan(&m.ID, &m.ConversationID, &m.ParentID, &m.Role, &m.Content, &m.Model, &m.TokensUsed, &m.Duration, &m.Interrupted,
+		&toolCalls, &m.ToolName, &m.SettingsVersion, &options, &m.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if toolCalls != "" {
+		m.ToolCalls = json.RawMessage(toolCalls)
+	}
+	if options != "" {
+		m.Options = json.RawMessage(options)
+	}
+	return m, nil
+}
+
+func scanMessages(rows *sql.Rows) ([]Message, error) {
+	defer rows.Close()
+
+	var msgs []Message
+	for rows.Next() {
+		m, err := scanMessage(rows)
+		if err != nil {
+			return nil, err
+		}
+		msgs = append(msgs, *m)
+	}
+	return msgs, rows.Err()
+}
+
+type scanFunc func(dest ...any) error
+
+func (f scanFunc) Scan(dest ...any) error {
+	return f(dest...)
+}
+
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+func (d *DB) CreateConversation(id, userID, title, model string) (*Conversation, error) {
 	now := time.Now()
 	_, err := d.conn.Exec(
-		"INSERT INTO conversations (id, title, model, created_at, updated_at) VALUES (?, ?, ?, ?, ?)",
-		id, title, model, now, now,
+		"INSERT INTO conversations (id, user_id, title, model, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?)",
+		id, userID, title, model, now, now,
 	)
 	if err != nil {
 		return nil, err
 	}
-	return &Conversation{ID: id, Title: title, Model: model, CreatedAt: now, UpdatedAt: now}, nil
+	return &Conversation{ID: id, UserID: userID, Title: title, Model: model, CreatedAt: now, UpdatedAt: now}, nil
 }
 
-func (d *DB) GetConversation(id string) (*Conversation, error) {
-	row := d.conn.QueryRow("SELECT id, title, model, created_at, updated_at FROM conversations WHERE id = ?", id)
-	c := &Conversation{}
-	err := row.Scan(&c.ID, &c.Title, &c.Model, &c.CreatedAt, &c.UpdatedAt)
+func (d *DB) GetConversation(id, userID string) (*Conversation, error) {
+	c, err := scanConversation(d.conn.QueryRow(
+		"SELECT "+conversationColumns+" FROM conversations c WHERE c.id = ? AND c.user_id = ?",
+		id, userID,
+	))
 	if err != nil {
 		return nil, err
 	}
-	return c, nil
+	convos := []Conversation{*c}
+	if err := d.attachTags(convos); err != nil {
+		return nil, err
+	}
+	return &convos[0], nil
 }
 
-func (d *DB) ListConversations() ([]Conversation, error) {
-	rows, err := d.conn.Query("SELECT id, title, model, created_at, updated_at FROM conversations ORDER BY updated_at DESC")
+func (d *DB) ListConversations(userID string) ([]Conversation, error) {
+	rows, err := d.conn.Query(
+		"SELECT "+conversationColumns+" FROM conversations c WHERE c.user_id = ? AND c.deleted_at IS NULL ORDER BY c.updated_at DESC",
+		userID,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -110,23 +478,139 @@ func (d *DB) ListConversations() ([]Conversation, error) {
 
 	var convos []Conversation
 	for rows.Next() {
-		c := Conversation{}
-		if err := rows.Scan(&c.ID, &c.Title, &c.Model, &c.CreatedAt, &c.UpdatedAt); err != nil {
+		c, err := scanConversation(rows)
+		if err != nil {
 			return nil, err
 		}
-		convos = append(convos, c)
+		convos = append(convos, *c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
 	}
-	return convos, nil
+	return convos, d.attachTags(convos)
 }
 
-func (d *DB) UpdateConversationTitle(id, title string) error {
-	_, err := d.conn.Exec("UPDATE conversations SET title = ?, updated_at = ? WHERE id = ?", title, time.Now(), id)
-	return err
+func (d *DB) UpdateConversationTitle(id, userID, title string) error {
+	res, err := d.conn.Exec(
+		"UPDATE conversations SET title = ?, updated_at = ? WHERE id = ? AND user_id = ?",
+		title, time.Now(), id, userID,
+	)
+	if err != nil {
+		return err
+	}
+	return expectAffected(res)
 }
 
-func (d *DB) DeleteConversation(id string) error {
-	_, err := d.conn.Exec("DELETE FROM conversations WHERE id = ?", id)
-	return err
+type ConversationUpdate struct {
+	Title    *string
+	FolderID *string
+	TagIDs   *[]string
+	Pinned   *bool
+	Archived *bool
+	Settings *ConversationSettings
+}
+
+func (d *DB) UpdateConversation(id, userID string, u ConversationUpdate) error {
+	tx, err := d.conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var exists int
+	err = tx.QueryRow("SELECT 1 FROM conversations WHERE id = ? AND user_id = ?", id, userID).Scan(&exists)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	if u.Title != nil {
+		if _, err := tx.Exec("UPDATE conversations SET title = ?, updated_at = ? WHERE id = ?", *u.Title, time.Now(), id); err != nil {
+			return err
+		}
+	}
+	if u.FolderID != nil {
+		if *u.FolderID != "" {
+			err := tx.QueryRow("SELECT 1 FROM folders WHERE id = ? AND user_id = ?", *u.FolderID, userID).Scan(&exists)
+			if errors.Is(err, sql.ErrNoRows) {
+				return ErrFolderNotFound
+			}
+			if err != nil {
+				return err
+			}
+		}
+		if _, err := tx.Exec("UPDATE conversations SET folder_id = ? WHERE id = ?", nullString(*u.FolderID), id); err != nil {
+			return err
+		}
+	}
+	if u.Pinned != nil {
+		if _, err := tx.Exec("UPDATE conversations SET pinned = ? WHERE id = ?", *u.Pinned, id); err != nil {
+			return err
+		}
+	}
+	if u.Archived != nil {
+		var archivedAt *time.Time
+		if *u.Archived {
+			now := time.Now()
+			archivedAt = &now
+		}
+		if _, err := tx.Exec("UPDATE conversations SET archived_at = ? WHERE id = ?", archivedAt, id); err != nil {
+			return err
+		}
+	}
+	if u.TagIDs != nil {
+		if _, err := tx.Exec("DELETE FROM conversation_tags WHERE conversation_id = ?", id); err != nil {
+			return err
+		}
+		for _, tagID := range *u.TagIDs {
+			err := tx.QueryRow("SELECT 1 FROM tags WHERE id = ? AND user_id = ?", tagID, userID).Scan(&exists)
+			if errors.Is(err, sql.ErrNoRows) {
+				return ErrTagNotFound
+			}
+			if err != nil {
+				return err
+			}
+			if _, err := tx.Exec("INSERT OR IGNORE INTO conversation_tags (conversation_id, tag_id) VALUES (?, ?)", id, tagID); err != nil {
+				return err
+			}
+		}
+	}
+	if u.Settings != nil {
+		if err := insertSettings(tx, id, u.Settings); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (d *DB) DeleteConversation(id, userID string) error {
+	tx, err := d.conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	n, err := purgeConversations(tx, "id = ? AND user_id = ?", id, userID)
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrNotFound
+	}
+	return tx.Commit()
+}
+
+func (d *DB) UpdateConversationSummary(id, summary, throughMessageID string) error {
+	res, err := d.conn.Exec(
+		"UPDATE conversations SET summary = ?, summary_message_id = ?, summary_updated_at = ? WHERE id = ?",
+		summary, nullString(throughMessageID), time.Now(), id,
+	)
+	if err != nil {
+		return err
+	}
+	return expectAffected(res)
 }
 
 func (d *DB) TouchConversation(id string) error {
@@ -135,48 +619,85 @@ func (d *DB) TouchConversation(id string) error {
 }
 
 func (d *DB) CreateMessage(msg *Message) error {
-	_, err := d.conn.Exec(
-		"INSERT INTO messages (id, conversation_id, role, content, model, tokens_used, duration, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
-		msg.ID, msg.ConversationID, msg.Role, msg.Content, msg.Model, msg.TokensUsed, msg.Duration, msg.CreatedAt,
+	tx, err := d.conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(
+		"INSERT INTO messages (id, conversation_id, parent_id, role, content, model, tokens_used, duration, interrupted, tool_calls, tool_name, settings_version, options, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		msg.ID, msg.ConversationID, nullString(msg.ParentID), msg.Role, msg.Content, msg.Model, msg.TokensUsed, msg.Duration, msg.Interrupted,
+		string(msg.ToolCalls), msg.ToolName, msg.SettingsVersion, string(msg.Options), msg.CreatedAt,
 	)
-	return err
+	if err != nil {
+		return err
+	}
+	if err := insertAttachments(tx, msg); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("UPDATE conversations SET active_message_id = ? WHERE id = ?", msg.ID, msg.ConversationID); err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
-func (d *DB) GetMessages(conversationID string) ([]Message, error) {
-	rows, err := d.conn.Query(
-		"SELECT id, conversation_id, role, content, model, tokens_used, duration, created_at FROM messages WHERE conversation_id = ? ORDER BY created_at ASC",
-		conversationID,
+func (d *DB) GetMessage(id, conversationID, userID string) (*Message, error) {
+	return scanMessage(d.conn.QueryRow(`
+		SELECT `+messageColumns+`
+		FROM messages m
+		JOIN conversations c ON c.id = m.conversation_id
+		WHERE m.id = ? AND m.conversation_id = ? AND c.user_id = ?`,
+		id, conversationID, userID,
+	))
+}
+
+func (d *DB) GetMessages(conversationID, userID string) ([]Message, error) {
+	rows, err := d.conn.Query(`
+		SELECT `+messageColumns+`
+		FROM messages m
+		JOIN conversations c ON c.id = m.conversation_id
+		WHERE m.conversation_id = ? AND c.user_id = ?
+		ORDER BY m.created_at ASC`,
+		conversationID, userID,
 	)
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
-
-	var msgs []Message
-	for rows.Next() {
-		m := Message{}
-		if err := rows.Scan(&m.ID, &m.ConversationID, &m.Role, &m.Content, &m.Model, &m.TokensUsed, &m.Duration, &m.CreatedAt); err != nil {
-			return nil, err
-		}
-		msgs = append(msgs, m)
-	}
-	return msgs, nil
+	return scanMessages(rows)
 }
 
-func (d *DB) GetConversationStats() (map[string]interface{}, error) {
+func (d *DB) GetConversationStats(userID string) (map[string]interface{}, error) {
 	stats := make(map[string]interface{})
 
 	var totalConvos int
-	d.conn.QueryRow("SELECT COUNT(*) FROM conversations").Scan(&totalConvos)
+	d.conn.QueryRow("SELECT COUNT(*) FROM conversations WHERE user_id = ? AND deleted_at IS NULL", userID).Scan(&totalConvos)
 	stats["total_conversations"] = totalConvos
 
 	var totalMsgs int
-	d.conn.QueryRow("SELECT COUNT(*) FROM messages").Scan(&totalMsgs)
+	d.conn.QueryRow(
+		"SELECT COUNT(*) FROM messages m JOIN conversations c ON c.id = m.conversation_id WHERE c.user_id = ?",
+		userID,
+	).Scan(&totalMsgs)
 	stats["total_messages"] = totalMsgs
 
 	var totalTokens int
-	d.conn.QueryRow("SELECT COALESCE(SUM(tokens_used), 0) FROM messages").Scan(&totalTokens)
+	d.conn.QueryRow(
+		"SELECT COALESCE(SUM(m.tokens_used), 0) FROM messages m JOIN conversations c ON c.id = m.conversation_id WHERE c.user_id = ?",
+		userID,
+	).Scan(&totalTokens)
 	stats["total_tokens"] = totalTokens
 
 	return stats, nil
 }
+
+func expectAffected(res sql.Result) error {
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,143 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+)
+
+var (
+	ErrFolderNotFound = errors.New("folder not found")
+	ErrFolderCycle    = errors.New("folder cannot be moved into itself")
+)
+
+type Folder struct {
+	ID        string    `json:"id"`
+	UserID    string    `json:"user_id"`
+	ParentID  string    `json:"parent_id,omitempty"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+const folderColumns = "f.id, f.user_id, COALESCE(f.parent_id, ''), f.name, f.created_at, f.updated_at"
+
+func scanFolder(row interface{ Scan(...any) error }) (*Folder, error) {
+	f := &Folder{}
+	err := row.Scan(&f.ID, &f.UserID, &f.ParentID, &f.Name, &f.CreatedAt, &f.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+type querier interface {
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+func checkFolderParent(q querier, id, userID, parentID string) error {
+	for current := parentID; current != ""; {
+		if current == id {
+			return ErrFolderCycle
+		}
+		var next sql.NullString
+		err := q.QueryRow("SELECT parent_id FROM folders WHERE id = ? AND user_id = ?", current, userID).Scan(&next)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrFolderNotFound
+		}
+		if err != nil {
+			return err
+		}
+		current = next.String
+	}
+	return nil
+}
+
+func (d *DB) CreateFolder(f *Folder) error {
+	if err := checkFolderParent(d.conn, f.ID, f.UserID, f.ParentID); err != nil {
+		return err
+	}
+	now := time.Now()
+	f.CreatedAt, f.UpdatedAt = now, now
+	_, err := d.conn.Exec(
+		"INSERT INTO folders (id, user_id, parent_id, name, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?)",
+		f.ID, f.UserID, nullString(f.ParentID), f.Name, f.CreatedAt, f.UpdatedAt,
+	)
+	return err
+}
+
+func (d *DB) GetFolder(id, userID string) (*Folder, error) {
+	return scanFolder(d.conn.QueryRow("SELECT "+folderColumns+" FROM folders f WHERE f.id = ? AND f.user_id = ?", id, userID))
+}
+
+func (d *DB) ListFolders(userID string) ([]Folder, error) {
+	rows, err := d.conn.Query("SELECT "+folderColumns+" FROM folders f WHERE f.user_id = ? ORDER BY f.name", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var folders []Folder
+	for rows.Next() {
+		f, err := scanFolder(rows)
+		if err != nil {
+			return nil, err
+		}
+		folders = append(folders, *f)
+	}
+	return folders, rows.Err()
+}
+
+func (d *DB) UpdateFolder(id, userID, name, parentID string) error {
+	tx, err := d.conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := checkFolderParent(tx, id, userID, parentID); err != nil {
+		return err
+	}
+	res, err := tx.Exec(
+		"UPDATE folders SET name = ?, parent_id = ?, updated_at = ? WHERE id = ? AND user_id = ?",
+		name, nullString(parentID), time.Now(), id, userID,
+	)
+	if err != nil {
+		return err
+	}
+	if err := expectAffected(res); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (d *DB) DeleteFolder(id, userID string) error {
+	tx, err := d.conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var parent sql.NullString
+	err = tx.QueryRow("SELECT parent_id FROM folders WHERE id = ? AND user_id = ?", id, userID).Scan(&parent)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec("UPDATE folders SET parent_id = ? WHERE parent_id = ? AND user_id = ?", parent, id, userID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("UPDATE conversations SET folder_id = ? WHERE folder_id = ? AND user_id = ?", parent, id, userID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM folders WHERE id = ?", id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
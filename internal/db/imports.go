@@ -0,0 +1,56 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+)
+
+func (d *DB) ImportedConversationID(userID, source, externalID string) (string, error) {
+	var id string
+	err := d.conn.QueryRow(`
+		SELECT i.conversation_id
+		FROM conversation_imports i
+		JOIN conversations c ON c.id = i.conversation_id
+		WHERE i.user_id = ? AND i.source = ? AND i.external_id = ?`,
+		userID, source, externalID,
+	).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", ErrNotFound
+	}
+	return id, err
+}
+
+func (d *DB) ImportConversation(source, externalID string, c *Conversation, msgs []Message) error {
+	tx, err := d.conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(
+		"INSERT INTO conversations (id, user_id, title, model, active_message_id, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?)",
+		c.ID, c.UserID, c.Title, c.Model, nullString(c.ActiveMessageID), c.CreatedAt, c.UpdatedAt,
+	)
+	if err != nil {
+		return err
+	}
+	for _, m := range msgs {
+		_, err := tx.Exec(
+			"INSERT INTO messages (id, conversation_id, parent_id, role, content, model, tokens_used, duration, interrupted, tool_calls, tool_name, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+			m.ID, c.ID, nullString(m.ParentID), m.Role, m.Content, m.Model, m.TokensUsed, m.Duration, m.Interrupted,
+			string(m.ToolCalls), m.ToolName, m.CreatedAt,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	_, err = tx.Exec(
+		"INSERT OR REPLACE INTO conversation_imports (user_id, source, external_id, conversation_id, imported_at) VALUES (?, ?, ?, ?, ?)",
+		c.UserID, source, externalID, c.ID, time.Now(),
+	)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
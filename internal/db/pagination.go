@@ -0,0 +1,313 @@
+package db
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+const (
+	DefaultPageSize = 50
+	MaxPageSize     = 200
+)
+
+const (
+	ViewArchived = "archived"
+	ViewTrash    = "trash"
+	ViewAll      = "all"
+)
+
+const (
+	SortUpdatedDesc = "updated_desc"
+	SortUpdatedAsc  = "updated_asc"
+	SortCreatedDesc = "created_desc"
+	SortCreatedAsc  = "created_asc"
+)
+
+type cursor struct {
+	Pinned bool   `json:"p,omitempty"`
+	Key    string `json:"k"`
+	ID     string `json:"id"`
+}
+
+func (c cursor) encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(s string) (*cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var c cursor
+	if err := json.Unmarshal(data, &c); err != nil || c.ID == "" {
+		return nil, ErrInvalidCursor
+	}
+	return &c, nil
+}
+
+type keysetQuery struct {
+	where []string
+	args  []interface{}
+}
+
+func (q *keysetQuery) add(clause string, args ...interface{}) {
+	q.where = append(q.where, clause)
+	q.args = append(q.args, args...)
+}
+
+func (q *keysetQuery) page(pinnedColumn, column, idColumn string, desc bool, after string) error {
+	if after == "" {
+		return nil
+	}
+	c, err := decodeCursor(after)
+	if err != nil {
+		return err
+	}
+	op := ">"
+	if desc {
+		op = "<"
+	}
+	clause := "(" + column + " " + op + " ? OR (" + column + " = ? AND " + idColumn + " " + op + " ?))"
+	args := []interface{}{c.Key, c.Key, c.ID}
+	if pinnedColumn != "" {
+		clause = "(" + pinnedColumn + " < ? OR (" + pinnedColumn + " = ? AND " + clause + "))"
+		args = append([]interface{}{c.Pinned, c.Pinned}, args...)
+	}
+	q.add(clause, args...)
+	return nil
+}
+
+func (q *keysetQuery) sql() string {
+	return strings.Join(q.where, " AND ")
+}
+
+func orderDirection(desc bool) string {
+	if desc {
+		return "DESC"
+	}
+	return "ASC"
+}
+
+type ConversationListOptions struct {
+	Limit    int
+	Cursor   string
+	Model    string
+	TagID    string
+	FolderID string
+	From     *time.Time
+	To       *time.Time
+	Sort     string
+	View     string
+}
+
+func ValidConversationView(s string) bool {
+	switch s {
+	case "", ViewArchived, ViewTrash, ViewAll:
+		return true
+	}
+	return false
+}
+
+func ValidConversationSort(s string) bool {
+	switch s {
+	case "", SortUpdatedDesc, SortUpdatedAsc, SortCreatedDesc, SortCreatedAsc:
+		return true
+	}
+	return false
+}
+
+func clampLimit(n int) int {
+	if n <= 0 {
+		return DefaultPageSize
+	}
+	return min(n, MaxPageSize)
+}
+
+func (d *DB) ListConversationsPage(userID string, opts ConversationListOptions) ([]Conversation, string, error) {
+	limit := clampLimit(opts.Limit)
+
+	column, desc := "c.updated_at", true
+	switch opts.Sort {
+	case SortUpdatedAsc:
+		desc = false
+	case SortCreatedDesc:
+		column = "c.created_at"
+	case SortCreatedAsc:
+		column, desc = "c.created_at", false
+	}
+	keyColumn := "CAST(" + column + " AS TEXT)"
+
+	q := &keysetQuery{}
+	q.add("c.user_id = ?", userID)
+	switch opts.View {
+	case ViewArchived:
+		q.add("c.deleted_at IS NULL AND c.archived_at IS NOT NULL")
+	case ViewTrash:
+		q.add("c.deleted_at IS NOT NULL")
+	case ViewAll:
+		q.add("c.deleted_at IS NULL")
+	default:
+		q.add("c.deleted_at IS NULL AND c.archived_at IS NULL")
+	}
+	if opts.Model != "" {
+		q.add("c.model = ?", opts.Model)
+	}
+	if opts.TagID != "" {
+		q.add("EXISTS (SELECT 1 FROM conversation_tags ct WHERE ct.conversation_id = c.id AND ct.tag_id = ?)", opts.TagID)
+	}
+	if opts.FolderID != "" {
+		q.add(`c.folder_id IN (
+			WITH RECURSIVE tree(id) AS (
+				SELECT id FROM folders WHERE id = ?
+				UNION ALL
+				SELECT f.id FROM folders f JOIN tree ON f.parent_id = tree.id
+			)
+			SELECT id FROM tree
+		)`, opts.FolderID)
+	}
+	if opts.From != nil {
+		q.add(column+" >= ?", *opts.From)
+	}
+	if opts.To != nil {
+		q.add(column+" < ?", *opts.To)
+	}
+	if err := q.page("c.pinned", keyColumn, "c.id", desc, opts.Cursor); err != nil {
+		return nil, "", err
+	}
+
+	dir := orderDirection(desc)
+	rows, err := d.conn.Query(
+		"SELECT "+conversationColumns+", "+keyColumn+" FROM conversations c WHERE "+q.sql()+
+			" ORDER BY c.pinned DESC, "+keyColumn+" "+dir+", c.id "+dir+" LIMIT ?",
+		append(q.args, limit+1)...,
+	)
+	if err != nil {
+		return nil, "", err
+	}
+	defer rows.Close()
+
+	var convos []Conversation
+	var keys []string
+	for rows.Next() {
+		var key string
+		c, err := scanConversation(scanFunc(func(dest ...any) error {
+			return rows.Scan(append(dest, &key)...)
+		}))
+		if err != nil {
+			return nil, "", err
+		}
+		convos = append(convos, *c)
+		keys = append(keys, key)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, "", err
+	}
+
+	var next string
+	if len(convos) > limit {
+		convos = convos[:limit]
+		last := convos[limit-1]
+		next = cursor{Pinned: last.Pinned, Key: keys[limit-1], ID: last.ID}.encode()
+	}
+	if err := d.attachTags(convos); err != nil {
+		return nil, "", err
+	}
+	return convos, next, nil
+}
+
+type MessageListOptions struct {
+	Limit  int
+	Cursor string
+	LeafID string
+	Role   string
+	Model  string
+	From   *time.Time
+	To     *time.Time
+	Desc   bool
+}
+
+func (d *DB) ListMessagesPage(conversationID, userID string, opts MessageListOptions) ([]Message, string, error) {
+	limit := clampLimit(opts.Limit)
+	const keyColumn = "CAST(m.created_at AS TEXT)"
+
+	q := &keysetQuery{}
+	q.add("m.conversation_id = ?", conversationID)
+	q.add("c.user_id = ?", userID)
+	if opts.LeafID != "" {
+		q.add("m.id IN (SELECT id FROM branch)")
+	}
+	if opts.Role != "" {
+		q.add("m.role = ?", opts.Role)
+	}
+	if opts.Model != "" {
+		q.add("m.model = ?", opts.Model)
+	}
+	if opts.From != nil {
+		q.add("m.created_at >= ?", *opts.From)
+	}
+	if opts.To != nil {
+		q.add("m.created_at < ?", *opts.To)
+	}
+	if err := q.page("", keyColumn, "m.id", opts.Desc, opts.Cursor); err != nil {
+		return nil, "", err
+	}
+
+	var prefix string
+	var args []interface{}
+	if opts.LeafID != "" {
+		prefix = `
+			WITH RECURSIVE branch(id) AS (
+				SELECT id FROM messages WHERE id = ? AND conversation_id = ?
+				UNION ALL
+				SELECT p.parent_id FROM branch b JOIN messages p ON p.id = b.id WHERE p.parent_id IS NOT NULL
+			)`
+		args = append(args, opts.LeafID, conversationID)
+	}
+	args = append(args, q.args...)
+	args = append(args, limit+1)
+
+	dir := orderDirection(opts.Desc)
+	rows, err := d.conn.Query(prefix+`
+		SELECT `+messageColumns+`, `+keyColumn+`
+		FROM messages m
+		JOIN conversations c ON c.id = m.conversation_id
+		WHERE `+q.sql()+`
+		ORDER BY `+keyColumn+` `+dir+`, m.id `+dir+`
+		LIMIT ?`,
+		args...,
+	)
+	if err != nil {
+		return nil, "", err
+	}
+	defer rows.Close()
+
+	var msgs []Message
+	var keys []string
+	for rows.Next() {
+		var key string
+		m, err := scanMessage(scanFunc(func(dest ...any) error {
+			return rows.Scan(append(dest, &key)...)
+		}))
+		if err != nil {
+			return nil, "", err
+		}
+		msgs = append(msgs, *m)
+		keys = append(keys, key)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, "", err
+	}
+
+	var next string
+	if len(msgs) > limit {
+		msgs = msgs[:limit]
+		next = cursor{Key: keys[limit-1], ID: msgs[limit-1].ID}.encode()
+	}
+	return msgs, next, nil
+}
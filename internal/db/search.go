@@ -0,0 +1,185 @@
+package db
+
+import (
+	"html"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	highlightStart = "\x02"
+	highlightEnd   = "\x03"
+)
+
+var highlightMarks = strings.NewReplacer(highlightStart, "<mark>", highlightEnd, "</mark>")
+
+func markHighlights(s string) string {
+	return highlightMarks.Replace(html.EscapeString(s))
+}
+
+type SearchOptions struct {
+	Query string
+	Model string
+	Role  string
+	From  *time.Time
+	To    *time.Time
+	Limit int
+}
+
+type SearchMatch struct {
+	MessageID string    `json:"message_id"`
+	Role      string    `json:"role"`
+	Snippet   string    `json:"snippet"`
+	Rank      float64   `json:"rank"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type SearchResult struct {
+	Conversation   Conversation  `json:"conversation"`
+	TitleHighlight string        `json:"title_highlight,omitempty"`
+	Rank           float64       `json:"rank"`
+	Matches        []SearchMatch `json:"matches"`
+}
+
+const maxMatchesPerConversation = 5
+
+func ftsQuery(q string) string {
+	terms := strings.Fields(q)
+	for i, t := range terms {
+		terms[i] = `"` + strings.ReplaceAll(t, `"`, `""`) + `"`
+	}
+	if len(terms) > 0 {
+		terms[len(terms)-1] += "*"
+	}
+	return strings.Join(terms, " ")
+}
+
+func (d *DB) Search(userID string, opts SearchOptions) ([]SearchResult, error) {
+	match := ftsQuery(opts.Query)
+	if match == "" {
+		return nil, nil
+	}
+	if opts.Limit <= 0 {
+		opts.Limit = 20
+	}
+
+	results := make(map[string]*SearchResult)
+	var order []string
+	add := func(c *Conversation, rank float64) *SearchResult {
+		r, ok := results[c.ID]
+		if !ok {
+			r = &SearchResult{Conversation: *c, Rank: rank, Matches: []SearchMatch{}}
+			results[c.ID] = r
+			order = append(order, c.ID)
+		}
+		if rank < r.Rank {
+			r.Rank = rank
+		}
+		return r
+	}
+
+	if opts.Role == "" {
+		query := `
+			SELECT ` + conversationColumns + `, highlight(conversations_fts, 0, ?, ?), bm25(conversations_fts) * 2 AS rank
+			FROM conversations_fts
+			JOIN conversations c ON c.rowid = conversations_fts.rowid
+			WHERE conversations_fts MATCH ? AND c.user_id = ? AND c.deleted_at IS NULL`
+		args := []interface{}{highlightStart, highlightEnd, match, userID}
+		if opts.Model != "" {
+			query += " AND c.model = ?"
+			args = append(args, opts.Model)
+		}
+		if opts.From != nil {
+			query += " AND c.updated_at >= ?"
+			args = append(args, *opts.From)
+		}
+		if opts.To != nil {
+			query += " AND c.updated_at < ?"
+			args = append(args, *opts.To)
+		}
+		query += " ORDER BY rank LIMIT ?"
+		args = append(args, opts.Limit)
+
+		rows, err := d.conn.Query(query, args...)
+		if err != nil {
+			return nil, err
+		}
+		for rows.Next() {
+			var highlight string
+			var rank float64
+			c, err := scanConversation(scanFunc(func(dest ...any) error {
+				return rows.Scan(append(dest, &highlight, &rank)...)
+			}))
+			if err != nil {
+				rows.Close()
+				return nil, err
+			}
+			add(c, rank).TitleHighlight = markHighlights(highlight)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	query := `
+		SELECT ` + conversationColumns + `, m.id, m.role, m.created_at,
+			snippet(messages_fts, 0, ?, ?, '…', 16), bm25(messages_fts) AS rank
+		FROM messages_fts
+		JOIN messages m ON m.rowid = messages_fts.rowid
+		JOIN conversations c ON c.id = m.conversation_id
+		WHERE messages_fts MATCH ? AND c.user_id = ? AND c.deleted_at IS NULL`
+	args := []interface{}{highlightStart, highlightEnd, match, userID}
+	if opts.Model != "" {
+		query += " AND (c.model = ? OR m.model = ?)"
+		args = append(args, opts.Model, opts.Model)
+	}
+	if opts.Role != "" {
+		query += " AND m.role = ?"
+		args = append(args, opts.Role)
+	}
+	if opts.From != nil {
+		query += " AND m.created_at >= ?"
+		args = append(args, *opts.From)
+	}
+	if opts.To != nil {
+		query += " AND m.created_at < ?"
+		args = append(args, *opts.To)
+	}
+	query += " ORDER BY rank LIMIT ?"
+	args = append(args, opts.Limit*maxMatchesPerConversation)
+
+	rows, err := d.conn.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var m SearchMatch
+		c, err := scanConversation(scanFunc(func(dest ...any) error {
+			return rows.Scan(append(dest, &m.MessageID, &m.Role, &m.CreatedAt, &m.Snippet, &m.Rank)...)
+		}))
+		if err != nil {
+			return nil, err
+		}
+		m.Snippet = markHighlights(m.Snippet)
+		r := add(c, m.Rank)
+		if len(r.Matches) < maxMatchesPerConversation {
+			r.Matches = append(r.Matches, m)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	out := make([]SearchResult, 0, len(order))
+	for _, id := range order {
+		out = append(out, *results[id])
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Rank < out[j].Rank })
+	if len(out) > opts.Limit {
+		out = out[:opts.Limit]
+	}
+	return out, nil
+}
@@ -0,0 +1,165 @@
+package db
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestDB(t *testing.T) *DB {
+	t.Helper()
+	d, err := New(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	t.Cleanup(func() { d.Close() })
+	return d
+}
+
+func mustConversation(t *testing.T, d *DB, id, userID, title, model string, msgs ...Message) {
+	t.Helper()
+	if _, err := d.CreateConversation(id, userID, title, model); err != nil {
+		t.Fatalf("create conversation: %v", err)
+	}
+	for i := range msgs {
+		msgs[i].ConversationID = id
+		if msgs[i].CreatedAt.IsZero() {
+			msgs[i].CreatedAt = time.Now()
+		}
+		if err := d.CreateMessage(&msgs[i]); err != nil {
+			t.Fatalf("create message: %v", err)
+		}
+	}
+}
+
+func searchIDs(t *testing.T, d *DB, userID string, opts SearchOptions) []string {
+	t.Helper()
+	results, err := d.Search(userID, opts)
+	if err != nil {
+		t.Fatalf("search %q: %v", opts.Query, err)
+	}
+	var ids []string
+	for _, r := range results {
+		ids = append(ids, r.Conversation.ID)
+	}
+	return ids
+}
+
+func TestSearchIndexesTitlesAndMessages(t *testing.T) {
+	d := newTestDB(t)
+	mustConversation(t, d, "c1", "u1", "Kubernetes notes", "llama3",
+		Message{ID: "m1", Role: "user", Content: "how do I drain a node?"},
+		Message{ID: "m2", ParentID: "m1", Role: "assistant", Content: "Use kubectl drain <node> --ignore-daemonsets", Model: "llama3"},
+	)
+	mustConversation(t, d, "c2", "u1", "Dinner", "mistral",
+		Message{ID: "m3", Role: "user", Content: "a recipe with <script>alert(1)</script> paprika"},
+	)
+	mustConversation(t, d, "c3", "u2", "Other user", "llama3",
+		Message{ID: "m4", Role: "user", Content: "drain the pasta"},
+	)
+
+	if ids := searchIDs(t, d, "u1", SearchOptions{Query: "kubernetes"}); len(ids) != 1 || ids[0] != "c1" {
+		t.Fatalf("title search = %v", ids)
+	}
+	if ids := searchIDs(t, d, "u1", SearchOptions{Query: "dra"}); len(ids) != 1 || ids[0] != "c1" {
+		t.Fatalf("prefix search = %v, want only the user's own conversation", ids)
+	}
+
+	results, _ := d.Search("u1", SearchOptions{Query: "paprika"})
+	if len(results) != 1 || len(results[0].Matches) != 1 {
+		t.Fatalf("results = %+v", results)
+	}
+	if snippet := results[0].Matches[0].Snippet; strings.Contains(snippet, "<script>") || !strings.Contains(snippet, "&lt;script&gt;") || !strings.Contains(snippet, "<mark>paprika</mark>") {
+		t.Fatalf("snippet = %q", snippet)
+	}
+	results, _ = d.Search("u1", SearchOptions{Query: "kubernetes"})
+	if results[0].TitleHighlight != "<mark>Kubernetes</mark> notes" {
+		t.Fatalf("title highlight = %q", results[0].TitleHighlight)
+	}
+}
+
+func TestSearchTriggersFollowChanges(t *testing.T) {
+	d := newTestDB(t)
+	mustConversation(t, d, "c1", "u1", "Old title", "llama3",
+		Message{ID: "m1", Role: "user", Content: "original wording"},
+	)
+
+	if err := d.UpdateConversationTitle("c1", "u1", "Fresh title"); err != nil {
+		t.Fatal(err)
+	}
+	if ids := searchIDs(t, d, "u1", SearchOptions{Query: "old"}); len(ids) != 0 {
+		t.Fatalf("stale title still indexed: %v", ids)
+	}
+	if ids := searchIDs(t, d, "u1", SearchOptions{Query: "fresh"}); len(ids) != 1 {
+		t.Fatalf("renamed title not indexed: %v", ids)
+	}
+
+	if _, err := d.conn.Exec("UPDATE messages SET content = ? WHERE id = ?", "rewritten text", "m1"); err != nil {
+		t.Fatal(err)
+	}
+	if ids := searchIDs(t, d, "u1", SearchOptions{Query: "wording"}); len(ids) != 0 {
+		t.Fatalf("stale message still indexed: %v", ids)
+	}
+	if ids := searchIDs(t, d, "u1", SearchOptions{Query: "rewritten"}); len(ids) != 1 {
+		t.Fatalf("updated message not indexed: %v", ids)
+	}
+
+	if err := d.TrashConversation("c1", "u1"); err != nil {
+		t.Fatal(err)
+	}
+	if ids := searchIDs(t, d, "u1", SearchOptions{Query: "rewritten"}); len(ids) != 0 {
+		t.Fatalf("trashed conversation found: %v", ids)
+	}
+	if err := d.RestoreConversation("c1", "u1"); err != nil {
+		t.Fatal(err)
+	}
+	if ids := searchIDs(t, d, "u1", SearchOptions{Query: "rewritten"}); len(ids) != 1 {
+		t.Fatalf("restored conversation not found: %v", ids)
+	}
+
+	if err := d.DeleteConversation("c1", "u1"); err != nil {
+		t.Fatal(err)
+	}
+	var n int
+	d.conn.QueryRow("SELECT COUNT(*) FROM messages_fts WHERE messages_fts MATCH 'rewritten'").Scan(&n)
+	if n != 0 {
+		t.Fatalf("deleted message left %d index rows", n)
+	}
+	d.conn.QueryRow("SELECT COUNT(*) FROM conversations_fts WHERE conversations_fts MATCH 'fresh'").Scan(&n)
+	if n != 0 {
+		t.Fatalf("deleted conversation left %d index rows", n)
+	}
+}
+
+func TestSearchFiltersAndRanking(t *testing.T) {
+	d := newTestDB(t)
+	day := time.Date(2026, 1, 10, 12, 0, 0, 0, time.UTC)
+	mustConversation(t, d, "weak", "u1", "Misc", "llama3",
+		Message{ID: "w1", Role: "user", Content: "a long message that mentions golang once among many other unrelated words about cooking and travel", CreatedAt: day},
+	)
+	mustConversation(t, d, "strong", "u1", "Golang", "mistral",
+		Message{ID: "s1", Role: "assistant", Content: "golang golang golang", Model: "mistral", CreatedAt: day.AddDate(0, 0, 5)},
+	)
+
+	if ids := searchIDs(t, d, "u1", SearchOptions{Query: "golang"}); len(ids) != 2 || ids[0] != "strong" {
+		t.Fatalf("ranking = %v, want strong first", ids)
+	}
+	if ids := searchIDs(t, d, "u1", SearchOptions{Query: "golang", Role: "user"}); len(ids) != 1 || ids[0] != "weak" {
+		t.Fatalf("role filter = %v", ids)
+	}
+	if ids := searchIDs(t, d, "u1", SearchOptions{Query: "golang", Model: "mistral"}); len(ids) != 1 || ids[0] != "strong" {
+		t.Fatalf("model filter = %v", ids)
+	}
+	to := day.AddDate(0, 0, 1)
+	if ids := searchIDs(t, d, "u1", SearchOptions{Query: "golang", Role: "user", To: &to}); len(ids) != 1 || ids[0] != "weak" {
+		t.Fatalf("date filter = %v", ids)
+	}
+	from := day.AddDate(0, 0, 2)
+	if ids := searchIDs(t, d, "u1", SearchOptions{Query: "golang", Role: "assistant", From: &from}); len(ids) != 1 || ids[0] != "strong" {
+		t.Fatalf("from filter = %v", ids)
+	}
+	if ids := searchIDs(t, d, "u1", SearchOptions{Query: "golang", Limit: 1}); len(ids) != 1 {
+		t.Fatalf("limit = %v", ids)
+	}
+}
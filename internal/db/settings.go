@@ -0,0 +1,92 @@
+package db
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+type ConversationSettings struct {
+	Version      int             `json:"version"`
+	SystemPrompt string          `json:"system_prompt"`
+	Model        string          `json:"model"`
+	Options      json.RawMessage `json:"options,omitempty"`
+	CreatedAt    time.Time       `json:"created_at"`
+}
+
+const settingsColumns = "version, system_prompt, model, options, created_at"
+
+func scanSettings(row interface{ Scan(...any) error }) (*ConversationSettings, error) {
+	s := &ConversationSettings{}
+	var options string
+	err := row.Scan(&s.Version, &s.SystemPrompt, &s.Model, &options, &s.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if options != "" {
+		s.Options = json.RawMessage(options)
+	}
+	return s, nil
+}
+
+func (d *DB) GetConversationSettings(conversationID string) (*ConversationSettings, error) {
+	return scanSettings(d.conn.QueryRow(
+		"SELECT "+settingsColumns+" FROM conversation_settings WHERE conversation_id = ? ORDER BY version DESC LIMIT 1",
+		conversationID,
+	))
+}
+
+func (d *DB) ListConversationSettings(conversationID string) ([]ConversationSettings, error) {
+	rows, err := d.conn.Query(
+		"SELECT "+settingsColumns+" FROM conversation_settings WHERE conversation_id = ? ORDER BY version",
+		conversationID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	versions := []ConversationSettings{}
+	for rows.Next() {
+		s, err := scanSettings(rows)
+		if err != nil {
+			return nil, err
+		}
+		versions = append(versions, *s)
+	}
+	return versions, rows.Err()
+}
+
+func (d *DB) SaveConversationSettings(conversationID string, s *ConversationSettings) error {
+	tx, err := d.conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := insertSettings(tx, conversationID, s); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func insertSettings(tx *sql.Tx, conversationID string, s *ConversationSettings) error {
+	if s.CreatedAt.IsZero() {
+		s.CreatedAt = time.Now()
+	}
+	if err := tx.QueryRow(
+		"SELECT COALESCE(MAX(version), 0) + 1 FROM conversation_settings WHERE conversation_id = ?",
+		conversationID,
+	).Scan(&s.Version); err != nil {
+		return err
+	}
+	_, err := tx.Exec(
+		"INSERT INTO conversation_settings (conversation_id, version, system_prompt, model, options, created_at) VALUES (?, ?, ?, ?, ?, ?)",
+		conversationID, s.Version, s.SystemPrompt, s.Model, string(s.Options), s.CreatedAt,
+	)
+	return err
+}
@@ -0,0 +1,97 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+)
+
+type Share struct {
+	ID             string     `json:"id"`
+	UserID         string     `json:"user_id"`
+	ConversationID string     `json:"conversation_id"`
+	TokenHash      string     `json:"-"`
+	Prefix         string     `json:"prefix"`
+	IncludeSystem  bool       `json:"include_system"`
+	Snapshot       string     `json:"-"`
+	CreatedAt      time.Time  `json:"created_at"`
+	ExpiresAt      *time.Time `json:"expires_at"`
+	RevokedAt      *time.Time `json:"revoked_at"`
+}
+
+func (s *Share) Active(now time.Time) bool {
+	if s.RevokedAt != nil {
+		return false
+	}
+	return s.ExpiresAt == nil || now.Before(*s.ExpiresAt)
+}
+
+const shareColumns = "s.id, s.user_id, s.conversation_id, s.token_hash, s.prefix, s.include_system, s.snapshot, s.created_at, s.expires_at, s.revoked_at"
+
+func scanShare(row interface{ Scan(...any) error }) (*Share, error) {
+	s := &Share{}
+	var expires, revoked sql.NullTime
+	err := row.Scan(&s.ID, &s.UserID, &s.ConversationID, &s.TokenHash, &s.Prefix, &s.IncludeSystem, &s.Snapshot, &s.CreatedAt, &expires, &revoked)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	s.ExpiresAt = nullTimePtr(expires)
+	s.RevokedAt = nullTimePtr(revoked)
+	return s, nil
+}
+
+func (d *DB) CreateShare(s *Share) error {
+	if s.CreatedAt.IsZero() {
+		s.CreatedAt = time.Now()
+	}
+	_, err := d.conn.Exec(
+		"INSERT INTO shares (id, user_id, conversation_id, token_hash, prefix, include_system, snapshot, created_at, expires_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		s.ID, s.UserID, s.ConversationID, s.TokenHash, s.Prefix, s.IncludeSystem, s.Snapshot, s.CreatedAt, s.ExpiresAt,
+	)
+	return err
+}
+
+func (d *DB) GetShareByHash(tokenHash string) (*Share, error) {
+	return scanShare(d.conn.QueryRow(`
+		SELECT `+shareColumns+`
+		FROM shares s
+		JOIN conversations c ON c.id = s.conversation_id
+		WHERE s.token_hash = ? AND c.deleted_at IS NULL`,
+		tokenHash,
+	))
+}
+
+func (d *DB) ListShares(conversationID, userID string) ([]Share, error) {
+	rows, err := d.conn.Query(
+		"SELECT "+shareColumns+" FROM shares s WHERE s.conversation_id = ? AND s.user_id = ? ORDER BY s.created_at DESC",
+		conversationID, userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var shares []Share
+	for rows.Next() {
+		s, err := scanShare(rows)
+		if err != nil {
+			return nil, err
+		}
+		shares = append(shares, *s)
+	}
+	return shares, rows.Err()
+}
+
+func (d *DB) RevokeShare(id, userID string) error {
+	res, err := d.conn.Exec(
+		"UPDATE shares SET revoked_at = ? WHERE id = ? AND user_id = ? AND revoked_at IS NULL",
+		time.Now(), id, userID,
+	)
+	if err != nil {
+		return err
+	}
+	return expectAffected(res)
+}
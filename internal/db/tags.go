@@ -0,0 +1,137 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+)
+
+var ErrTagNotFound = errors.New("tag not found")
+
+type Tag struct {
+	ID        string    `json:"id"`
+	UserID    string    `json:"user_id"`
+	Name      string    `json:"name"`
+	Color     string    `json:"color,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+const tagColumns = "t.id, t.user_id, t.name, t.color, t.created_at"
+
+func scanTag(row interface{ Scan(...any) error }) (*Tag, error) {
+	t := &Tag{}
+	err := row.Scan(&t.ID, &t.UserID, &t.Name, &t.Color, &t.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+func (d *DB) CreateTag(t *Tag) error {
+	if t.CreatedAt.IsZero() {
+		t.CreatedAt = time.Now()
+	}
+	_, err := d.conn.Exec(
+		"INSERT INTO tags (id, user_id, name, color, created_at) VALUES (?, ?, ?, ?, ?)",
+		t.ID, t.UserID, t.Name, t.Color, t.CreatedAt,
+	)
+	return err
+}
+
+func (d *DB) GetTag(id, userID string) (*Tag, error) {
+	return scanTag(d.conn.QueryRow("SELECT "+tagColumns+" FROM tags t WHERE t.id = ? AND t.user_id = ?", id, userID))
+}
+
+func (d *DB) GetTagByName(userID, name string) (*Tag, error) {
+	return scanTag(d.conn.QueryRow("SELECT "+tagColumns+" FROM tags t WHERE t.user_id = ? AND t.name = ?", userID, name))
+}
+
+func (d *DB) ListTags(userID string) ([]Tag, error) {
+	rows, err := d.conn.Query("SELECT "+tagColumns+" FROM tags t WHERE t.user_id = ? ORDER BY t.name", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tags []Tag
+	for rows.Next() {
+		t, err := scanTag(rows)
+		if err != nil {
+			return nil, err
+		}
+		tags = append(tags, *t)
+	}
+	return tags, rows.Err()
+}
+
+func (d *DB) UpdateTag(id, userID, name, color string) error {
+	res, err := d.conn.Exec(
+		"UPDATE tags SET name = ?, color = ? WHERE id = ? AND user_id = ?",
+		name, color, id, userID,
+	)
+	if err != nil {
+		return err
+	}
+	return expectAffected(res)
+}
+
+func (d *DB) DeleteTag(id, userID string) error {
+	tx, err := d.conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec("DELETE FROM tags WHERE id = ? AND user_id = ?", id, userID)
+	if err != nil {
+		return err
+	}
+	if err := expectAffected(res); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM conversation_tags WHERE tag_id = ?", id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (d *DB) attachTags(convos []Conversation) error {
+	if len(convos) == 0 {
+		return nil
+	}
+	index := make(map[string]int, len(convos))
+	args := make([]interface{}, len(convos))
+	for i := range convos {
+		index[convos[i].ID] = i
+		args[i] = convos[i].ID
+		convos[i].Tags = []Tag{}
+	}
+
+	rows, err := d.conn.Query(
+		"SELECT ct.conversation_id, "+tagColumns+" FROM conversation_tags ct JOIN tags t ON t.id = ct.tag_id"+
+			" WHERE ct.conversation_id IN (?"+strings.Repeat(", ?", len(args)-1)+") ORDER BY t.name",
+		args...,
+	)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var convID string
+		t, err := scanTag(scanFunc(func(dest ...any) error {
+			return rows.Scan(append([]any{&convID}, dest...)...)
+		}))
+		if err != nil {
+			return err
+		}
+		if i, ok := index[convID]; ok {
+			convos[i].Tags = append(convos[i].Tags, *t)
+		}
+	}
+	return rows.Err()
+}
@@ -0,0 +1,116 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+)
+
+type APIToken struct {
+	ID         string     `json:"id"`
+	UserID     string     `json:"user_id"`
+	Name       string     `json:"name"`
+	TokenHash  string     `json:"-"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+}
+
+func (t *APIToken) Active(now time.Time) bool {
+	if t.RevokedAt != nil {
+		return false
+	}
+	return t.ExpiresAt == nil || now.Before(*t.ExpiresAt)
+}
+
+func (t *APIToken) HasScope(scope string) bool {
+	for _, s := range t.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+const apiTokenColumns = "id, user_id, name, token_hash, prefix, scopes, created_at, last_used_at, expires_at, revoked_at"
+
+func scanAPIToken(row interface{ Scan(...any) error }) (*APIToken, error) {
+	t := &APIToken{}
+	var scopes string
+	var lastUsed, expires, revoked sql.NullTime
+	err := row.Scan(&t.ID, &t.UserID, &t.Name, &t.TokenHash, &t.Prefix, &scopes, &t.CreatedAt, &lastUsed, &expires, &revoked)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	t.Scopes = []string{}
+	if scopes != "" {
+		t.Scopes = strings.Split(scopes, ",")
+	}
+	t.LastUsedAt = nullTimePtr(lastUsed)
+	t.ExpiresAt = nullTimePtr(expires)
+	t.RevokedAt = nullTimePtr(revoked)
+	return t, nil
+}
+
+func nullTimePtr(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
+
+func (d *DB) CreateAPIToken(t *APIToken) error {
+	if t.CreatedAt.IsZero() {
+		t.CreatedAt = time.Now()
+	}
+	_, err := d.conn.Exec(
+		"INSERT INTO api_tokens (id, user_id, name, token_hash, prefix, scopes, created_at, expires_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
+		t.ID, t.UserID, t.Name, t.TokenHash, t.Prefix, strings.Join(t.Scopes, ","), t.CreatedAt, t.ExpiresAt,
+	)
+	return err
+}
+
+func (d *DB) GetAPITokenByHash(tokenHash string) (*APIToken, error) {
+	return scanAPIToken(d.conn.QueryRow("SELECT "+apiTokenColumns+" FROM api_tokens WHERE token_hash = ?", tokenHash))
+}
+
+func (d *DB) ListAPITokens(userID string) ([]APIToken, error) {
+	rows, err := d.conn.Query("SELECT "+apiTokenColumns+" FROM api_tokens WHERE user_id = ? ORDER BY created_at DESC", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tokens []APIToken
+	for rows.Next() {
+		t, err := scanAPIToken(rows)
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, *t)
+	}
+	return tokens, rows.Err()
+}
+
+func (d *DB) RevokeAPIToken(id, userID string) error {
+	res, err := d.conn.Exec(
+		"UPDATE api_tokens SET revoked_at = ? WHERE id = ? AND user_id = ? AND revoked_at IS NULL",
+		time.Now(), id, userID,
+	)
+	if err != nil {
+		return err
+	}
+	return expectAffected(res)
+}
+
+func (d *DB) TouchAPIToken(id string) error {
+	_, err := d.conn.Exec("UPDATE api_tokens SET last_used_at = ? WHERE id = ?", time.Now(), id)
+	return err
+}
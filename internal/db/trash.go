@@ -0,0 +1,71 @@
+package db
+
+import (
+	"database/sql"
+	"time"
+)
+
+func purgeConversations(tx *sql.Tx, where string, args ...interface{}) (int64, error) {
+	stmts := []string{
+		"DELETE FROM messages WHERE conversation_id IN (SELECT id FROM conversations WHERE " + where + ")",
+		"DELETE FROM attachments WHERE conversation_id IN (SELECT id FROM conversations WHERE " + where + ")",
+		"DELETE FROM conversation_tags WHERE conversation_id IN (SELECT id FROM conversations WHERE " + where + ")",
+		"DELETE FROM conversation_imports WHERE conversation_id IN (SELECT id FROM conversations WHERE " + where + ")",
+		"DELETE FROM shares WHERE conversation_id IN (SELECT id FROM conversations WHERE " + where + ")",
+		"DELETE FROM conversation_settings WHERE conversation_id IN (SELECT id FROM conversations WHERE " + where + ")",
+	}
+	for _, stmt := range stmts {
+		if _, err := tx.Exec(stmt, args...); err != nil {
+			return 0, err
+		}
+	}
+	res, err := tx.Exec("DELETE FROM conversations WHERE "+where, args...)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+func (d *DB) TrashConversation(id, userID string) error {
+	res, err := d.conn.Exec(
+		"UPDATE conversations SET deleted_at = ? WHERE id = ? AND user_id = ? AND deleted_at IS NULL",
+		time.Now(), id, userID,
+	)
+	if err != nil {
+		return err
+	}
+	return expectAffected(res)
+}
+
+func (d *DB) RestoreConversation(id, userID string) error {
+	res, err := d.conn.Exec(
+		"UPDATE conversations SET deleted_at = NULL WHERE id = ? AND user_id = ? AND deleted_at IS NOT NULL",
+		id, userID,
+	)
+	if err != nil {
+		return err
+	}
+	return expectAffected(res)
+}
+
+func (d *DB) EmptyTrash(userID string) (int64, error) {
+	return d.purge("user_id = ? AND deleted_at IS NOT NULL", userID)
+}
+
+func (d *DB) PurgeTrash(before time.Time) (int64, error) {
+	return d.purge("deleted_at IS NOT NULL AND deleted_at < ?", before)
+}
+
+func (d *DB) purge(where string, args ...interface{}) (int64, error) {
+	tx, err := d.conn.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	n, err := purgeConversations(tx, where, args...)
+	if err != nil {
+		return 0, err
+	}
+	return n, tx.Commit()
+}
@@ -0,0 +1,253 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+)
+
+var ErrLastAdmin = errors.New("at least one admin is required")
+
+const keepsAnAdmin = "(role != 'admin' OR (SELECT COUNT(*) FROM users WHERE role = 'admin') > 1)"
+
+type Role string
+
+const (
+	RoleAdmin    Role = "admin"
+	RoleMember   Role = "member"
+	RoleReadOnly Role = "readonly"
+)
+
+var roleRank = map[Role]int{
+	RoleReadOnly: 1,
+	RoleMember:   2,
+	RoleAdmin:    3,
+}
+
+func (r Role) Valid() bool {
+	_, ok := roleRank[r]
+	return ok
+}
+
+func (r Role) AtLeast(min Role) bool {
+	return roleRank[r] >= roleRank[min]
+}
+
+type User struct {
+	ID           string    `json:"id"`
+	Username     string    `json:"username"`
+	PasswordHash string    `json:"-"`
+	Role         Role      `json:"role"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+type Session struct {
+	TokenHash string
+	UserID    string
+	CreatedAt time.Time
+	ExpiresAt time.Time
+}
+
+const userColumns = "id, username, password_hash, role, created_at"
+
+func scanUser(row interface{ Scan(...any) error }) (*User, error) {
+	u := &User{}
+	err := row.Scan(&u.ID, &u.Username, &u.PasswordHash, &u.Role, &u.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+func (d *DB) CreateUser(u *User) error {
+	if u.CreatedAt.IsZero() {
+		u.CreatedAt = time.Now()
+	}
+	if u.Role == "" {
+		u.Role = RoleMember
+	}
+	_, err := d.conn.Exec(
+		"INSERT INTO users (id, username, password_hash, role, created_at) VALUES (?, ?, ?, ?, ?)",
+		u.ID, u.Username, u.PasswordHash, u.Role, u.CreatedAt,
+	)
+	return err
+}
+
+func (d *DB) GetUser(id string) (*User, error) {
+	return scanUser(d.conn.QueryRow("SELECT "+userColumns+" FROM users WHERE id = ?", id))
+}
+
+func (d *DB) GetUserByUsername(username string) (*User, error) {
+	return scanUser(d.conn.QueryRow("SELECT "+userColumns+" FROM users WHERE username = ?", username))
+}
+
+func (d *DB) ListUsers() ([]User, error) {
+	rows, err := d.conn.Query("SELECT " + userColumns + " FROM users ORDER BY created_at ASC")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []User
+	for rows.Next() {
+		u, err := scanUser(rows)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, *u)
+	}
+	return users, rows.Err()
+}
+
+func (d *DB) CountUsers() (int, error) {
+	var n int
+	err := d.conn.QueryRow("SELECT COUNT(*) FROM users").Scan(&n)
+	return n, err
+}
+
+func (d *DB) UpdateUserPassword(id, passwordHash string) error {
+	res, err := d.conn.Exec("UPDATE users SET password_hash = ? WHERE id = ?", passwordHash, id)
+	if err != nil {
+		return err
+	}
+	if err := expectAffected(res); err != nil {
+		return err
+	}
+	_, err = d.conn.Exec("DELETE FROM sessions WHERE user_id = ?", id)
+	return err
+}
+
+func (d *DB) UpdateUserRole(id string, role Role) error {
+	query := "UPDATE users SET role = ? WHERE id = ?"
+	if role != RoleAdmin {
+		query += " AND " + keepsAnAdmin
+	}
+	res, err := d.conn.Exec(query, role, id)
+	if err != nil {
+		return err
+	}
+	return expectAdminKept(d.conn, res, id)
+}
+
+func expectAdminKept(q interface {
+	QueryRow(string, ...any) *sql.Row
+}, res sql.Result, id string) error {
+	if err := expectAffected(res); !errors.Is(err, ErrNotFound) {
+		return err
+	}
+	var n int
+	if err := q.QueryRow("SELECT COUNT(*) FROM users WHERE id = ?", id).Scan(&n); err != nil {
+		return err
+	}
+	if n > 0 {
+		return ErrLastAdmin
+	}
+	return ErrNotFound
+}
+
+func (d *DB) DeleteUser(id string) error {
+	tx, err := d.conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec("DELETE FROM users WHERE id = ? AND "+keepsAnAdmin, id)
+	if err != nil {
+		return err
+	}
+	if err := expectAdminKept(tx, res, id); err != nil {
+		return err
+	}
+	stmts := []string{
+		"DELETE FROM sessions WHERE user_id = ?",
+		"DELETE FROM api_tokens WHERE user_id = ?",
+		"DELETE FROM messages WHERE conversation_id IN (SELECT id FROM conversations WHERE user_id = ?)",
+		"DELETE FROM attachments WHERE user_id = ?",
+		"DELETE FROM conversation_tags WHERE tag_id IN (SELECT id FROM tags WHERE user_id = ?)",
+		"DELETE FROM tags WHERE user_id = ?",
+		"DELETE FROM folders WHERE user_id = ?",
+		"DELETE FROM conversation_imports WHERE user_id = ?",
+		"DELETE FROM shares WHERE user_id = ?",
+		"DELETE FROM conversation_settings WHERE conversation_id IN (SELECT id FROM conversations WHERE user_id = ?)",
+		"DELETE FROM conversations WHERE user_id = ?",
+	}
+	for _, stmt := range stmts {
+		if _, err := tx.Exec(stmt, id); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (d *DB) AssignOrphanConversations(userID string) (int64, error) {
+	res, err := d.conn.Exec("UPDATE conversations SET user_id = ? WHERE user_id = ''", userID)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+func (d *DB) CreateSession(s *Session) error {
+	_, err := d.conn.Exec(
+		"INSERT INTO sessions (token_hash, user_id, created_at, expires_at) VALUES (?, ?, ?, ?)",
+		s.TokenHash, s.UserID, s.CreatedAt, s.ExpiresAt,
+	)
+	return err
+}
+
+func (d *DB) GetSessionUser(tokenHash string) (*User, error) {
+	var userID string
+	var expiresAt time.Time
+	err := d.conn.QueryRow("SELECT user_id, expires_at FROM sessions WHERE token_hash = ?", tokenHash).Scan(&userID, &expiresAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if time.Now().After(expiresAt) {
+		d.DeleteSession(tokenHash)
+		return nil, ErrNotFound
+	}
+	return d.GetUser(userID)
+}
+
+func (d *DB) DeleteSession(tokenHash string) error {
+	_, err := d.conn.Exec("DELETE FROM sessions WHERE token_hash = ?", tokenHash)
+	return err
+}
+
+func (d *DB) DeleteExpiredSessions(userID string) error {
+	rows, err := d.conn.Query("SELECT token_hash, expires_at FROM sessions WHERE user_id = ?", userID)
+	if err != nil {
+		return err
+	}
+	var expired []string
+	now := time.Now()
+	for rows.Next() {
+		var hash string
+		var expiresAt time.Time
+		if err := rows.Scan(&hash, &expiresAt); err != nil {
+			rows.Close()
+			return err
+		}
+		if now.After(expiresAt) {
+			expired = append(expired, hash)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, hash := range expired {
+		if err := d.DeleteSession(hash); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package db
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestLastAdminKept(t *testing.T) {
+	d := newTestDB(t)
+	for _, u := range []*User{
+		{ID: "a1", Username: "root", Role: RoleAdmin},
+		{ID: "a2", Username: "ops", Role: RoleAdmin},
+		{ID: "m1", Username: "member", Role: RoleMember},
+	} {
+		if err := d.CreateUser(u); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := d.UpdateUserRole("a2", RoleMember); err != nil {
+		t.Fatalf("demote one of two admins: %v", err)
+	}
+	if err := d.UpdateUserRole("a1", RoleReadOnly); !errors.Is(err, ErrLastAdmin) {
+		t.Fatalf("demote the last admin = %v, want ErrLastAdmin", err)
+	}
+	if err := d.DeleteUser("a1"); !errors.Is(err, ErrLastAdmin) {
+		t.Fatalf("delete the last admin = %v, want ErrLastAdmin", err)
+	}
+	if err := d.UpdateUserRole("a1", RoleAdmin); err != nil {
+		t.Fatalf("keep the last admin an admin: %v", err)
+	}
+	if err := d.UpdateUserRole("m1", RoleReadOnly); err != nil {
+		t.Fatalf("change a member: %v", err)
+	}
+	if err := d.DeleteUser("m1"); err != nil {
+		t.Fatalf("delete a member: %v", err)
+	}
+	if err := d.DeleteUser("missing"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("delete a missing user = %v, want ErrNotFound", err)
+	}
+	if err := d.UpdateUserRole("missing", RoleMember); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("update a missing user = %v, want ErrNotFound", err)
+	}
+	if u, err := d.GetUser("a1"); err != nil || u.Role != RoleAdmin {
+		t.Fatalf("last admin = %+v, %v", u, err)
+	}
+}
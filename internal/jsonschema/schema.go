@@ -0,0 +1,464 @@
+package jsonschema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+type Schema struct {
+	Types                []string
+	Properties           map[string]*Schema
+	Required             []string
+	AdditionalProperties *Schema
+	NoAdditional         bool
+	Items                *Schema
+	Enum                 []interface{}
+	Const                interface{}
+	HasConst             bool
+	Minimum              *float64
+	Maximum              *float64
+	ExclusiveMinimum     *float64
+	ExclusiveMaximum     *float64
+	MinLength            *int
+	MaxLength            *int
+	Pattern              *regexp.Regexp
+	MinItems             *int
+	MaxItems             *int
+	UniqueItems          bool
+	AllOf                []*Schema
+	AnyOf                []*Schema
+	OneOf                []*Schema
+	Not                  *Schema
+}
+
+type ValidationError struct {
+	Path    string
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return e.Path + ": " + e.Message
+}
+
+var schemaTypes = map[string]bool{
+	"object":  true,
+	"array":   true,
+	"string":  true,
+	"number":  true,
+	"integer": true,
+	"boolean": true,
+	"null":    true,
+}
+
+var knownKeywords = map[string]bool{
+	"type":                 true,
+	"properties":           true,
+	"required":             true,
+	"additionalProperties": true,
+	"items":                true,
+	"enum":                 true,
+	"const":                true,
+	"minimum":              true,
+	"maximum":              true,
+	"exclusiveMinimum":     true,
+	"exclusiveMaximum":     true,
+	"minLength":            true,
+	"maxLength":            true,
+	"pattern":              true,
+	"minItems":             true,
+	"maxItems":             true,
+	"uniqueItems":          true,
+	"allOf":                true,
+	"anyOf":                true,
+	"oneOf":                true,
+	"not":                  true,
+
+	"$schema":     true,
+	"$id":         true,
+	"$comment":    true,
+	"title":       true,
+	"description": true,
+	"default":     true,
+	"examples":    true,
+	"format":      true,
+	"deprecated":  true,
+	"readOnly":    true,
+	"writeOnly":   true,
+}
+
+func Compile(raw json.RawMessage) (*Schema, error) {
+	var doc interface{}
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return nil, fmt.Errorf("schema is not valid JSON: %w", err)
+	}
+	return compile(doc, "$")
+}
+
+func compile(doc interface{}, path string) (*Schema, error) {
+	if b, ok := doc.(bool); ok {
+		if b {
+			return &Schema{}, nil
+		}
+		return &Schema{Not: &Schema{}}, nil
+	}
+	obj, ok := doc.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%s: schema must be an object", path)
+	}
+	keys := make([]string, 0, len(obj))
+	for key := range obj {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if !knownKeywords[key] {
+			return nil, fmt.Errorf("%s: unsupported keyword %q", path, key)
+		}
+	}
+
+	s := &Schema{}
+	var err error
+	switch t := obj["type"].(type) {
+	case nil:
+	case string:
+		s.Types = []string{t}
+	case []interface{}:
+		for _, v := range t {
+			name, ok := v.(string)
+			if !ok {
+				return nil, fmt.Errorf("%s.type: must be a string or a list of strings", path)
+			}
+			s.Types = append(s.Types, name)
+		}
+	default:
+		return nil, fmt.Errorf("%s.type: must be a string or a list of strings", path)
+	}
+	for _, name := range s.Types {
+		if !schemaTypes[name] {
+			return nil, fmt.Errorf("%s.type: unknown type %q", path, name)
+		}
+	}
+
+	if props, ok := obj["properties"]; ok {
+		m, ok := props.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%s.properties: must be an object", path)
+		}
+		s.Properties = make(map[string]*Schema, len(m))
+		for name, sub := range m {
+			if s.Properties[name], err = compile(sub, path+".properties."+name); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if req, ok := obj["required"]; ok {
+		list, ok := req.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%s.required: must be a list of strings", path)
+		}
+		for _, v := range list {
+			name, ok := v.(string)
+			if !ok {
+				return nil, fmt.Errorf("%s.required: must be a list of strings", path)
+			}
+			s.Required = append(s.Required, name)
+		}
+	}
+	switch ap := obj["additionalProperties"].(type) {
+	case nil:
+	case bool:
+		s.NoAdditional = !ap
+	default:
+		if s.AdditionalProperties, err = compile(ap, path+".additionalProperties"); err != nil {
+			return nil, err
+		}
+	}
+	if items, ok := obj["items"]; ok {
+		if s.Items, err = compile(items, path+".items"); err != nil {
+			return nil, err
+		}
+	}
+	if enum, ok := obj["enum"]; ok {
+		if s.Enum, ok = enum.([]interface{}); !ok {
+			return nil, fmt.Errorf("%s.enum: must be a list", path)
+		}
+	}
+	s.Const, s.HasConst = obj["const"]
+
+	for key, dest := range map[string]**float64{
+		"minimum":          &s.Minimum,
+		"maximum":          &s.Maximum,
+		"exclusiveMinimum": &s.ExclusiveMinimum,
+		"exclusiveMaximum": &s.ExclusiveMaximum,
+	} {
+		if v, ok := obj[key]; ok {
+			n, ok := v.(float64)
+			if !ok {
+				return nil, fmt.Errorf("%s.%s: must be a number", path, key)
+			}
+			*dest = &n
+		}
+	}
+	for key, dest := range map[string]**int{
+		"minLength": &s.MinLength,
+		"maxLength": &s.MaxLength,
+		"minItems":  &s.MinItems,
+		"maxItems":  &s.MaxItems,
+	} {
+		if v, ok := obj[key]; ok {
+			n, ok := v.(float64)
+			if !ok || n < 0 || n != math.Trunc(n) {
+				return nil, fmt.Errorf("%s.%s: must be a non-negative integer", path, key)
+			}
+			i := int(n)
+			*dest = &i
+		}
+	}
+	if v, ok := obj["pattern"]; ok {
+		pattern, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("%s.pattern: must be a string", path)
+		}
+		if s.Pattern, err = regexp.Compile(pattern); err != nil {
+			return nil, fmt.Errorf("%s.pattern: %w", path, err)
+		}
+	}
+	if v, ok := obj["uniqueItems"]; ok {
+		if s.UniqueItems, ok = v.(bool); !ok {
+			return nil, fmt.Errorf("%s.uniqueItems: must be a boolean", path)
+		}
+	}
+
+	for key, dest := range map[string]*[]*Schema{
+		"allOf": &s.AllOf,
+		"anyOf": &s.AnyOf,
+		"oneOf": &s.OneOf,
+	} {
+		v, ok := obj[key]
+		if !ok {
+			continue
+		}
+		list, ok := v.([]interface{})
+		if !ok || len(list) == 0 {
+			return nil, fmt.Errorf("%s.%s: must be a non-empty list of schemas", path, key)
+		}
+		for i, sub := range list {
+			compiled, err := compile(sub, fmt.Sprintf("%s.%s[%d]", path, key, i))
+			if err != nil {
+				return nil, err
+			}
+			*dest = append(*dest, compiled)
+		}
+	}
+	if v, ok := obj["not"]; ok {
+		if s.Not, err = compile(v, path+".not"); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+func (s *Schema) ValidateJSON(data []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return &ValidationError{Path: "$", Message: "not valid JSON: " + err.Error()}
+	}
+	if dec.More() {
+		return &ValidationError{Path: "$", Message: "unexpected data after the JSON value"}
+	}
+	return s.Validate(v)
+}
+
+func (s *Schema) Validate(v interface{}) error {
+	return s.validate(v, "$")
+}
+
+func (s *Schema) validate(v interface{}, path string) error {
+	fail := func(format string, args ...interface{}) error {
+		return &ValidationError{Path: path, Message: fmt.Sprintf(format, args...)}
+	}
+
+	if len(s.Types) > 0 && !s.matchesType(v) {
+		return fail("expected %s, got %s", strings.Join(s.Types, " or "), typeOf(v))
+	}
+	if s.HasConst && !equal(v, s.Const) {
+		return fail("must be %s", encode(s.Const))
+	}
+	if s.Enum != nil {
+		found := false
+		for _, e := range s.Enum {
+			if equal(v, e) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			options := make([]string, len(s.Enum))
+			for i, e := range s.Enum {
+				options[i] = encode(e)
+			}
+			return fail("must be one of %s", strings.Join(options, ", "))
+		}
+	}
+
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for _, name := range s.Required {
+			if _, ok := val[name]; !ok {
+				return fail("missing required property %q", name)
+			}
+		}
+		names := make([]string, 0, len(val))
+		for name := range val {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			sub, ok := s.Properties[name]
+			switch {
+			case ok:
+			case s.NoAdditional:
+				return fail("unexpected property %q", name)
+			case s.AdditionalProperties != nil:
+				sub = s.AdditionalProperties
+			default:
+				continue
+			}
+			if err := sub.validate(val[name], path+"."+name); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		if s.MinItems != nil && len(val) < *s.MinItems {
+			return fail("must have at least %d items", *s.MinItems)
+		}
+		if s.MaxItems != nil && len(val) > *s.MaxItems {
+			return fail("must have at most %d items", *s.MaxItems)
+		}
+		if s.UniqueItems {
+			for i := range val {
+				for j := 0; j < i; j++ {
+					if equal(val[i], val[j]) {
+						return fail("items %d and %d are equal", j, i)
+					}
+				}
+			}
+		}
+		if s.Items != nil {
+			for i, item := range val {
+				if err := s.Items.validate(item, path+"["+strconv.Itoa(i)+"]"); err != nil {
+					return err
+				}
+			}
+		}
+	case string:
+		n := utf8.RuneCountInString(val)
+		if s.MinLength != nil && n < *s.MinLength {
+			return fail("must be at least %d characters", *s.MinLength)
+		}
+		if s.MaxLength != nil && n > *s.MaxLength {
+			return fail("must be at most %d characters", *s.MaxLength)
+		}
+		if s.Pattern != nil && !s.Pattern.MatchString(val) {
+			return fail("must match pattern %q", s.Pattern.String())
+		}
+	case float64:
+		if s.Minimum != nil && val < *s.Minimum {
+			return fail("must be >= %v", *s.Minimum)
+		}
+		if s.Maximum != nil && val > *s.Maximum {
+			return fail("must be <= %v", *s.Maximum)
+		}
+		if s.ExclusiveMinimum != nil && val <= *s.ExclusiveMinimum {
+			return fail("must be > %v", *s.ExclusiveMinimum)
+		}
+		if s.ExclusiveMaximum != nil && val >= *s.ExclusiveMaximum {
+			return fail("must be < %v", *s.ExclusiveMaximum)
+		}
+	}
+
+	for _, sub := range s.AllOf {
+		if err := sub.validate(v, path); err != nil {
+			return err
+		}
+	}
+	if len(s.AnyOf) > 0 {
+		var first error
+		for _, sub := range s.AnyOf {
+			err := sub.validate(v, path)
+			if err == nil {
+				first = nil
+				break
+			}
+			if first == nil {
+				first = err
+			}
+		}
+		if first != nil {
+			return first
+		}
+	}
+	if len(s.OneOf) > 0 {
+		matched := 0
+		for _, sub := range s.OneOf {
+			if sub.validate(v, path) == nil {
+				matched++
+			}
+		}
+		if matched != 1 {
+			return fail("must match exactly one schema in oneOf, matched %d", matched)
+		}
+	}
+	if s.Not != nil && s.Not.validate(v, path) == nil {
+		return fail("must not match the schema in not")
+	}
+	return nil
+}
+
+func (s *Schema) matchesType(v interface{}) bool {
+	actual := typeOf(v)
+	for _, t := range s.Types {
+		if t == actual || (t == "number" && actual == "integer") {
+			return true
+		}
+	}
+	return false
+}
+
+func typeOf(v interface{}) string {
+	switch val := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case float64:
+		if val == math.Trunc(val) {
+			return "integer"
+		}
+		return "number"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+func equal(a, b interface{}) bool {
+	return encode(a) == encode(b)
+}
+
+func encode(v interface{}) string {
+	data, _ := json.Marshal(v)
+	return string(data)
+}
@@ -0,0 +1,56 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestJSONSchemaValidate(t *testing.T) {
+	schema, err := Compile(json.RawMessage(`{
+		"type": "object",
+		"additionalProperties": false,
+		"required": ["tags"],
+		"properties": {
+			"kind": {"enum": ["a", "b"]},
+			"tags": {"type": "array", "items": {"type": "string", "pattern": "^[a-z]+$"}, "maxItems": 2, "uniqueItems": true},
+			"score": {"type": ["number", "null"], "exclusiveMaximum": 1}
+		}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for doc, want := range map[string]string{
+		`{"tags": ["x"], "kind": "a", "score": 0.5}`: "",
+		`{"tags": [], "score": null}`:                "",
+		`{"tags": ["x"]} trailing`:                   "$: ",
+		`{"kind": "a"}`:                              `$: missing required property "tags"`,
+		`{"tags": ["x", "Y"]}`:                       "$.tags[1]: must match pattern",
+		`{"tags": ["x", "x"]}`:                       "$.tags: items 0 and 1 are equal",
+		`{"tags": [], "kind": "c"}`:                  `$.kind: must be one of "a", "b"`,
+		`{"tags": [], "score": 1}`:                   "$.score: must be < 1",
+		`{"tags": [], "extra": 1}`:                   `$: unexpected property "extra"`,
+		`{"tags": "x"}`:                              "$.tags: expected array, got string",
+	} {
+		err := schema.ValidateJSON([]byte(doc))
+		if want == "" && err != nil || want != "" && (err == nil || !strings.HasPrefix(err.Error(), want)) {
+			t.Errorf("ValidateJSON(%s) = %v, want %q", doc, err, want)
+		}
+	}
+	if _, err := Compile(json.RawMessage(`{"type": "object", "properties": {"a": {"minLength": -1}}}`)); err == nil {
+		t.Error("Compile accepted a negative minLength")
+	}
+	for schema, want := range map[string]string{
+		`{"$ref": "#/$defs/person"}`:                                     `$: unsupported keyword "$ref"`,
+		`{"type": "object", "properties": {"a": {"minProperties": 1}}}`:  `$.properties.a: unsupported keyword "minProperties"`,
+		`{"type": "array", "items": {"type": "string"}, "contains": {}}`: `$: unsupported keyword "contains"`,
+		`{"uniqueItems": "yes"}`:                                         "$.uniqueItems: must be a boolean",
+	} {
+		if _, err := Compile(json.RawMessage(schema)); err == nil || err.Error() != want {
+			t.Errorf("Compile(%s) = %v, want %q", schema, err, want)
+		}
+	}
+	if _, err := Compile(json.RawMessage(`{"$schema": "https://json-schema.org/draft/2020-12/schema", "title": "Person", "description": "d", "type": "string", "format": "date-time", "default": "x"}`)); err != nil {
+		t.Errorf("Compile rejected annotations: %v", err)
+	}
+}
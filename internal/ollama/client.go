@@ -3,10 +3,13 @@ package ollama
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -32,30 +35,80 @@ type Model struct {
 }
 
 type ChatMessage struct {
-	Role    string `json:"role"`
-	Content string `json:"content"`
+	Role      string     `json:"role"`
+	Content   string     `json:"content"`
+	Images    []string   `json:"images,omitempty"`
+	ToolCalls []ToolCall `json:"tool_calls,omitempty"`
+	ToolName  string     `json:"tool_name,omitempty"`
+}
+
+type ToolCall struct {
+	ID       string           `json:"id,omitempty"`
+	Function ToolCallFunction `json:"function"`
+}
+
+type ToolCallFunction struct {
+	Name      string                 `json:"name"`
+	Arguments map[string]interface{} `json:"arguments"`
+}
+
+type Tool struct {
+	Type     string       `json:"type"`
+	Function ToolFunction `json:"function"`
+}
+
+type ToolFunction struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	Parameters  json.RawMessage `json:"parameters"`
 }
 
 type ChatRequest struct {
-	Model    string        `json:"model"`
-	Messages []ChatMessage `json:"messages"`
-	Stream   bool          `json:"stream"`
-	Options  *Options      `json:"options,omitempty"`
+	Model     string          `json:"model"`
+	Messages  []ChatMessage   `json:"messages"`
+	Stream    bool            `json:"stream"`
+	Tools     []Tool          `json:"tools,omitempty"`
+	Format    json.RawMessage `json:"format,omitempty"`
+	Options   *Options        `json:"options,omitempty"`
+	KeepAlive json.RawMessage `json:"keep_alive,omitempty"`
 }
 
 type Options struct {
-	Temperature float64 `json:"temperature,omitempty"`
-	TopP        float64 `json:"top_p,omitempty"`
-	TopK        int     `json:"top_k,omitempty"`
-	NumPredict  int     `json:"num_predict,omitempty"`
-	Seed        int     `json:"seed,omitempty"`
+	NumKeep          *int     `json:"num_keep,omitempty"`
+	Seed             *int     `json:"seed,omitempty"`
+	NumPredict       *int     `json:"num_predict,omitempty"`
+	TopK             *int     `json:"top_k,omitempty"`
+	TopP             *float64 `json:"top_p,omitempty"`
+	MinP             *float64 `json:"min_p,omitempty"`
+	TypicalP         *float64 `json:"typical_p,omitempty"`
+	RepeatLastN      *int     `json:"repeat_last_n,omitempty"`
+	Temperature      *float64 `json:"temperature,omitempty"`
+	RepeatPenalty    *float64 `json:"repeat_penalty,omitempty"`
+	PresencePenalty  *float64 `json:"presence_penalty,omitempty"`
+	FrequencyPenalty *float64 `json:"frequency_penalty,omitempty"`
+	Mirostat         *int     `json:"mirostat,omitempty"`
+	MirostatTau      *float64 `json:"mirostat_tau,omitempty"`
+	MirostatEta      *float64 `json:"mirostat_eta,omitempty"`
+	PenalizeNewline  *bool    `json:"penalize_newline,omitempty"`
+	Stop             []string `json:"stop,omitempty"`
+	NumCtx           *int     `json:"num_ctx,omitempty"`
+	NumBatch         *int     `json:"num_batch,omitempty"`
+	NumGPU           *int     `json:"num_gpu,omitempty"`
+	MainGPU          *int     `json:"main_gpu,omitempty"`
+	UseMMap          *bool    `json:"use_mmap,omitempty"`
+	NumThread        *int     `json:"num_thread,omitempty"`
+}
+
+func Ptr[T any](v T) *T {
+	return &v
 }
 
 type ChatResponse struct {
-	Model     string      `json:"model"`
-	CreatedAt time.Time   `json:"created_at"`
-	Message   ChatMessage `json:"message"`
-	Done      bool        `json:"done"`
+	Model      string      `json:"model"`
+	CreatedAt  time.Time   `json:"created_at"`
+	Message    ChatMessage `json:"message"`
+	Done       bool        `json:"done"`
+	DoneReason string      `json:"done_reason,omitempty"`
 
 	TotalDuration      int64 `json:"total_duration,omitempty"`
 	LoadDuration       int64 `json:"load_duration,omitempty"`
@@ -77,12 +130,49 @@ type PullResponse struct {
 	Completed int64  `json:"completed,omitempty"`
 }
 
+type ShowResponse struct {
+	Parameters string                 `json:"parameters"`
+	ModelInfo  map[string]interface{} `json:"model_info"`
+}
+
+func (s *ShowResponse) NumCtx() int {
+	for _, line := range strings.Split(s.Parameters, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 2 && fields[0] == "num_ctx" {
+			if n, err := strconv.Atoi(fields[1]); err == nil {
+				return n
+			}
+		}
+	}
+	return 0
+}
+
+func (s *ShowResponse) ContextLength() int {
+	for key, val := range s.ModelInfo {
+		if strings.HasSuffix(key, ".context_length") {
+			if n, ok := val.(float64); ok {
+				return int(n)
+			}
+		}
+	}
+	return 0
+}
+
 type GenerateRequest struct {
 	Model  string `json:"model"`
 	Prompt string `json:"prompt"`
 	Stream bool   `json:"stream"`
 }
 
+type StatusError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("status %d: %s", e.StatusCode, e.Body)
+}
+
 func New(baseURL string) *Client {
 	return &Client{
 		baseURL: baseURL,
@@ -92,8 +182,13 @@ func New(baseURL string) *Client {
 	}
 }
 
-func (c *Client) ListModels() ([]Model, error) {
-	resp, err := c.httpClient.Get(c.baseURL + "/api/tags")
+func (c *Client) ListModels(ctx context.Context) ([]Model, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+"/api/tags", nil)
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+
+	resp, err := c.httpClient.Do(httpReq)
 	if err != nil {
 		return nil, fmt.Errorf("list models: %w", err)
 	}
@@ -101,7 +196,7 @@ func (c *Client) ListModels() ([]Model, error) {
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("list models: status %d: %s", resp.StatusCode, string(body))
+		return nil, fmt.Errorf("list models: %w", &StatusError{StatusCode: resp.StatusCode, Body: string(body)})
 	}
 
 	var result struct {
@@ -114,7 +209,33 @@ func (c *Client) ListModels() ([]Model, error) {
 	return result.Models, nil
 }
 
-func (c *Client) ChatStream(req *ChatRequest, onChunk func(ChatResponse) error) error {
+func (c *Client) ShowModel(ctx context.Context, name string) (*ShowResponse, error) {
+	body, _ := json.Marshal(map[string]string{"model": name})
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/api/show", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("show model: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("show model: %w", &StatusError{StatusCode: resp.StatusCode, Body: string(respBody)})
+	}
+
+	var show ShowResponse
+	if err := json.NewDecoder(resp.Body).Decode(&show); err != nil {
+		return nil, fmt.Errorf("decode model info: %w", err)
+	}
+	return &show, nil
+}
+
+func (c *Client) ChatStream(ctx context.Context, req *ChatRequest, onChunk func(ChatResponse) error) error {
 	req.Stream = true
 
 	body, err := json.Marshal(req)
@@ -122,7 +243,7 @@ func (c *Client) ChatStream(req *ChatRequest, onChunk func(ChatResponse) error)
 		return fmt.Errorf("marshal request: %w", err)
 	}
 
-	httpReq, err := http.NewRequest("POST", c.baseURL+"/api/chat", bytes.NewReader(body))
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/api/chat", bytes.NewReader(body))
 	if err != nil {
 		return fmt.Errorf("create request: %w", err)
 	}
@@ -136,7 +257,7 @@ func (c *Client) ChatStream(req *ChatRequest, onChunk func(ChatResponse) error)
 
 	if resp.StatusCode != http.StatusOK {
 		respBody, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("chat error: status %d: %s", resp.StatusCode, string(respBody))
+		return fmt.Errorf("chat error: %w", &StatusError{StatusCode: resp.StatusCode, Body: string(respBody)})
 	}
 
 	scanner := bufio.NewScanner(resp.Body)
@@ -165,7 +286,7 @@ func (c *Client) ChatStream(req *ChatRequest, onChunk func(ChatResponse) error)
 	return scanner.Err()
 }
 
-func (c *Client) Chat(req *ChatRequest) (*ChatResponse, error) {
+func (c *Client) Chat(ctx context.Context, req *ChatRequest) (*ChatResponse, error) {
 	req.Stream = false
 
 	body, err := json.Marshal(req)
@@ -173,7 +294,13 @@ func (c *Client) Chat(req *ChatRequest) (*ChatResponse, error) {
 		return nil, fmt.Errorf("marshal: %w", err)
 	}
 
-	resp, err := c.httpClient.Post(c.baseURL+"/api/chat", "application/json", bytes.NewReader(body))
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/api/chat", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(httpReq)
 	if err != nil {
 		return nil, fmt.Errorf("chat: %w", err)
 	}
@@ -181,7 +308,7 @@ func (c *Client) Chat(req *ChatRequest) (*ChatResponse, error) {
 
 	if resp.StatusCode != http.StatusOK {
 		respBody, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("chat error: status %d: %s", resp.StatusCode, string(respBody))
+		return nil, fmt.Errorf("chat error: %w", &StatusError{StatusCode: resp.StatusCode, Body: string(respBody)})
 	}
 
 	var chatResp ChatResponse
@@ -192,16 +319,27 @@ func (c *Client) Chat(req *ChatRequest) (*ChatResponse, error) {
 	return &chatResp, nil
 }
 
-func (c *Client) PullModel(name string, onProgress func(PullResponse) error) error {
+func (c *Client) PullModel(ctx context.Context, name string, onProgress func(PullResponse) error) error {
 	req := PullRequest{Name: name, Stream: true}
 	body, _ := json.Marshal(req)
 
-	resp, err := c.httpClient.Post(c.baseURL+"/api/pull", "application/json", bytes.NewReader(body))
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/api/pull", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(httpReq)
 	if err != nil {
 		return fmt.Errorf("pull model: %w", err)
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("pull error: %w", &StatusError{StatusCode: resp.StatusCode, Body: string(respBody)})
+	}
+
 	scanner := bufio.NewScanner(resp.Body)
 	scanner.Buffer(make([]byte, 1024*1024), 1024*1024)
 
@@ -226,9 +364,9 @@ func (c *Client) PullModel(name string, onProgress func(PullResponse) error) err
 	return scanner.Err()
 }
 
-func (c *Client) DeleteModel(name string) error {
+func (c *Client) DeleteModel(ctx context.Context, name string) error {
 	body, _ := json.Marshal(map[string]string{"name": name})
-	req, err := http.NewRequest("DELETE", c.baseURL+"/api/delete", bytes.NewReader(body))
+	req, err := http.NewRequestWithContext(ctx, "DELETE", c.baseURL+"/api/delete", bytes.NewReader(body))
 	if err != nil {
 		return err
 	}
@@ -242,48 +380,20 @@ func (c *Client) DeleteModel(name string) error {
 
 	if resp.StatusCode != http.StatusOK {
 		respBody, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("delete error: status %d: %s", resp.StatusCode, string(respBody))
+		return fmt.Errorf("delete error: %w", &StatusError{StatusCode: resp.StatusCode, Body: string(respBody)})
 	}
 	return nil
 }
 
-func (c *Client) IsHealthy() bool {
-	resp, err := c.httpClient.Get(c.baseURL + "/api/tags")
+func (c *Client) IsHealthy(ctx context.Context) bool {
+	req, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+"/api/tags", nil)
 	if err != nil {
 		return false
 	}
-	defer resp.Body.Close()
-	return resp.StatusCode == http.StatusOK
-}
-
-func (c *Client) GenerateTitle(model, userMessage string) (string, error) {
-	req := &ChatRequest{
-		Model: model,
-		Messages: []ChatMessage{
-			{
-				Role:    "system",
-				Content: "Generate a very short title (max 6 words) for a conversation that starts with the following message. Reply with ONLY the title, no quotes, no punctuation at the end.",
-			},
-			{
-				Role:    "user",
-				Content: userMessage,
-			},
-		},
-		Stream: false,
-		Options: &Options{
-			Temperature: 0.3,
-			NumPredict:  20,
-		},
-	}
-
-	resp, err := c.Chat(req)
+	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return "", err
-	}
-
-	title := resp.Message.Content
-	if len(title) > 80 {
-		title = title[:80]
+		return false
 	}
-	return title, nil
+	defer resp.Body.Close()
+	return resp.StatusCode == http.StatusOK
 }
@@ -0,0 +1,409 @@
+package provider
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ifauzeee/Zee-AI/internal/ollama"
+)
+
+type OpenAI struct {
+	baseURL    string
+	apiKey     string
+	httpClient *http.Client
+}
+
+func NewOpenAI(baseURL, apiKey string) *OpenAI {
+	return &OpenAI{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		apiKey:  apiKey,
+		httpClient: &http.Client{
+			Timeout: 10 * time.Minute,
+		},
+	}
+}
+
+type openAIRequest struct {
+	Model            string                `json:"model"`
+	Messages         []openAIMessage       `json:"messages"`
+	Stream           bool                  `json:"stream"`
+	StreamOptions    *openAIStreamOptions  `json:"stream_options,omitempty"`
+	Tools            []ollama.Tool         `json:"tools,omitempty"`
+	Temperature      *float64              `json:"temperature,omitempty"`
+	TopP             *float64              `json:"top_p,omitempty"`
+	TopK             *int                  `json:"top_k,omitempty"`
+	MaxTokens        *int                  `json:"max_tokens,omitempty"`
+	Seed             *int                  `json:"seed,omitempty"`
+	PresencePenalty  *float64              `json:"presence_penalty,omitempty"`
+	FrequencyPenalty *float64              `json:"frequency_penalty,omitempty"`
+	Stop             []string              `json:"stop,omitempty"`
+	ResponseFormat   *openAIResponseFormat `json:"response_format,omitempty"`
+}
+
+type openAIResponseFormat struct {
+	Type       string            `json:"type"`
+	JSONSchema *openAIJSONSchema `json:"json_schema,omitempty"`
+}
+
+type openAIJSONSchema struct {
+	Name   string          `json:"name"`
+	Schema json.RawMessage `json:"schema"`
+}
+
+type openAIStreamOptions struct {
+	IncludeUsage bool `json:"include_usage"`
+}
+
+type openAIMessage struct {
+	Role       string           `json:"role"`
+	Content    interface{}      `json:"content"`
+	ToolCalls  []openAIToolCall `json:"tool_calls,omitempty"`
+	ToolCallID string           `json:"tool_call_id,omitempty"`
+}
+
+type openAIContentPart struct {
+	Type     string          `json:"type"`
+	Text     string          `json:"text,omitempty"`
+	ImageURL *openAIImageURL `json:"image_url,omitempty"`
+}
+
+type openAIImageURL struct {
+	URL string `json:"url"`
+}
+
+type openAIReply struct {
+	Role      string           `json:"role"`
+	Content   string           `json:"content"`
+	ToolCalls []openAIToolCall `json:"tool_calls,omitempty"`
+}
+
+type openAIToolCall struct {
+	Index    *int   `json:"index,omitempty"`
+	ID       string `json:"id,omitempty"`
+	Type     string `json:"type,omitempty"`
+	Function struct {
+		Name      string `json:"name,omitempty"`
+		Arguments string `json:"arguments"`
+	} `json:"function"`
+}
+
+type openAIResponse struct {
+	Model   string `json:"model"`
+	Choices []struct {
+		Message      openAIReply `json:"message"`
+		Delta        openAIReply `json:"delta"`
+		FinishReason *string     `json:"finish_reason"`
+	} `json:"choices"`
+	Usage *struct {
+		PromptTokens     int `json:"prompt_tokens"`
+		CompletionTokens int `json:"completion_tokens"`
+	} `json:"usage"`
+}
+
+func (o *OpenAI) newRequest(ctx context.Context, method, path string, body interface{}) (*http.Request, error) {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("marshal request: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, o.baseURL+path, reader)
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if o.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+o.apiKey)
+	}
+	return req, nil
+}
+
+func (o *OpenAI) ListModels(ctx context.Context) ([]ollama.Model, error) {
+	req, err := o.newRequest(ctx, "GET", "/models", nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := o.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("list models: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("list models: status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var result struct {
+		Data []struct {
+			ID      string `json:"id"`
+			Created int64  `json:"created"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("decode models: %w", err)
+	}
+
+	models := make([]ollama.Model, 0, len(result.Data))
+	for _, m := range result.Data {
+		model := ollama.Model{Name: m.ID, Model: m.ID}
+		if m.Created > 0 {
+			model.ModifiedAt = time.Unix(m.Created, 0)
+		}
+		models = append(models, model)
+	}
+	return models, nil
+}
+
+func toOpenAIMessages(messages []ollama.ChatMessage) []openAIMessage {
+	out := make([]openAIMessage, 0, len(messages))
+	var pending []string
+	for i, m := range messages {
+		msg := openAIMessage{Role: m.Role, Content: m.Content}
+		if len(m.Images) > 0 {
+			parts := []openAIContentPart{{Type: "text", Text: m.Content}}
+			for _, img := range m.Images {
+				parts = append(parts, openAIContentPart{
+					Type:     "image_url",
+					ImageURL: &openAIImageURL{URL: "data:" + imageType(img) + ";base64," + img},
+				})
+			}
+			msg.Content = parts
+		}
+		for j, call := range m.ToolCalls {
+			tc := openAIToolCall{ID: call.ID, Type: "function"}
+			if tc.ID == "" {
+				tc.ID = fmt.Sprintf("call_%d_%d", i, j)
+			}
+			tc.Function.Name = call.Function.Name
+			args, _ := json.Marshal(call.Function.Arguments)
+			tc.Function.Arguments = string(args)
+			msg.ToolCalls = append(msg.ToolCalls, tc)
+			pending = append(pending, tc.ID)
+		}
+		if m.Role == "tool" && len(pending) > 0 {
+			msg.ToolCallID, pending = pending[0], pending[1:]
+		}
+		out = append(out, msg)
+	}
+	return out
+}
+
+func imageType(b64 string) string {
+	head := b64[:min(len(b64), 64)]
+	data, _ := base64.StdEncoding.DecodeString(head[:len(head)/4*4])
+	return http.DetectContentType(data)
+}
+
+func fromOpenAIToolCalls(calls []openAIToolCall) []ollama.ToolCall {
+	out := make([]ollama.ToolCall, 0, len(calls))
+	for _, c := range calls {
+		call := ollama.ToolCall{ID: c.ID, Function: ollama.ToolCallFunction{Name: c.Function.Name}}
+		if c.Function.Arguments != "" {
+			json.Unmarshal([]byte(c.Function.Arguments), &call.Function.Arguments)
+		}
+		out = append(out, call)
+	}
+	return out
+}
+
+func toOpenAIRequest(req *ollama.ChatRequest, stream bool) *openAIRequest {
+	out := &openAIRequest{
+		Model:    req.Model,
+		Messages: toOpenAIMessages(req.Messages),
+		Stream:   stream,
+		Tools:    req.Tools,
+	}
+	if stream {
+		out.StreamOptions = &openAIStreamOptions{IncludeUsage: true}
+	}
+	if o := req.Options; o != nil {
+		out.Temperature = o.Temperature
+		out.TopP = o.TopP
+		out.TopK = o.TopK
+		out.MaxTokens = o.NumPredict
+		out.Seed = o.Seed
+		out.PresencePenalty = o.PresencePenalty
+		out.FrequencyPenalty = o.FrequencyPenalty
+		out.Stop = o.Stop
+	}
+	if format := bytes.TrimSpace(req.Format); len(format) > 0 {
+		if format[0] == '{' {
+			out.ResponseFormat = &openAIResponseFormat{
+				Type:       "json_schema",
+				JSONSchema: &openAIJSONSchema{Name: "response", Schema: format},
+			}
+		} else {
+			out.ResponseFormat = &openAIResponseFormat{Type: "json_object"}
+		}
+	}
+	return out
+}
+
+func doneReason(finish string) string {
+	if finish == "length" {
+		return "length"
+	}
+	return "stop"
+}
+
+func (o *OpenAI) Chat(ctx context.Context, req *ollama.ChatRequest) (*ollama.ChatResponse, error) {
+	httpReq, err := o.newRequest(ctx, "POST", "/chat/completions", toOpenAIRequest(req, false))
+	if err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	resp, err := o.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("chat: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("chat error: status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var result openAIResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("decode: %w", err)
+	}
+	if len(result.Choices) == 0 {
+		return nil, fmt.Errorf("chat: response has no choices")
+	}
+
+	choice := result.Choices[0]
+	out := &ollama.ChatResponse{
+		Model:         req.Model,
+		CreatedAt:     time.Now(),
+		Message:       ollama.ChatMessage{Role: "assistant", Content: choice.Message.Content},
+		Done:          true,
+		TotalDuration: time.Since(start).Nanoseconds(),
+	}
+	if choice.FinishReason != nil {
+		out.DoneReason = doneReason(*choice.FinishReason)
+	}
+	if len(choice.Message.ToolCalls) > 0 {
+		out.Message.ToolCalls = fromOpenAIToolCalls(choice.Message.ToolCalls)
+	}
+	if result.Usage != nil {
+		out.PromptEvalCount = result.Usage.PromptTokens
+		out.EvalCount = result.Usage.CompletionTokens
+	}
+	return out, nil
+}
+
+func (o *OpenAI) ChatStream(ctx context.Context, req *ollama.ChatRequest, onChunk func(ollama.ChatResponse) error) error {
+	httpReq, err := o.newRequest(ctx, "POST", "/chat/completions", toOpenAIRequest(req, true))
+	if err != nil {
+		return err
+	}
+	start := time.Now()
+	resp, err := o.httpClient.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("chat request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("chat error: status %d: %s", resp.StatusCode, string(body))
+	}
+
+	final := ollama.ChatResponse{
+		Model:   req.Model,
+		Message: ollama.ChatMessage{Role: "assistant"},
+		Done:    true,
+	}
+
+	var calls []openAIToolCall
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 1024*1024), 1024*1024)
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data:")
+		if !ok {
+			continue
+		}
+		data = strings.TrimSpace(data)
+		if data == "[DONE]" {
+			break
+		}
+
+		var chunk openAIResponse
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			continue
+		}
+		if chunk.Usage != nil {
+			final.PromptEvalCount = chunk.Usage.PromptTokens
+			final.EvalCount = chunk.Usage.CompletionTokens
+		}
+		if len(chunk.Choices) == 0 {
+			continue
+		}
+		choice := chunk.Choices[0]
+		if choice.FinishReason != nil {
+			final.DoneReason = doneReason(*choice.FinishReason)
+		}
+		for _, delta := range choice.Delta.ToolCalls {
+			idx := len(calls)
+			if delta.Index != nil {
+				idx = *delta.Index
+			}
+			for len(calls) <= idx {
+				calls = append(calls, openAIToolCall{})
+			}
+			if delta.ID != "" {
+				calls[idx].ID = delta.ID
+			}
+			if delta.Function.Name != "" {
+				calls[idx].Function.Name = delta.Function.Name
+			}
+			calls[idx].Function.Arguments += delta.Function.Arguments
+		}
+		if choice.Delta.Content == "" {
+			continue
+		}
+		if err := onChunk(ollama.ChatResponse{
+			Model:     req.Model,
+			CreatedAt: time.Now(),
+			Message:   ollama.ChatMessage{Role: "assistant", Content: choice.Delta.Content},
+		}); err != nil {
+			return err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	if len(calls) > 0 {
+		final.Message.ToolCalls = fromOpenAIToolCalls(calls)
+	}
+	final.CreatedAt = time.Now()
+	final.TotalDuration = time.Since(start).Nanoseconds()
+	return onChunk(final)
+}
+
+func (o *OpenAI) IsHealthy(ctx context.Context) bool {
+	req, err := o.newRequest(ctx, "GET", "/models", nil)
+	if err != nil {
+		return false
+	}
+	resp, err := o.httpClient.Do(req)
+	if err != nil {
+		return false
+	}
+	defer resp.Body.Close()
+	return resp.StatusCode == http.StatusOK
+}
@@ -0,0 +1,361 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ifauzeee/Zee-AI/internal/ollama"
+)
+
+const (
+	BalanceLeastBusy  = "least_busy"
+	BalanceRoundRobin = "round_robin"
+)
+
+const healthCheckTimeout = 10 * time.Second
+
+type BackendStatus struct {
+	URL         string     `json:"url"`
+	Healthy     bool       `json:"healthy"`
+	InFlight    int        `json:"in_flight"`
+	Models      []string   `json:"models"`
+	LastChecked *time.Time `json:"last_checked,omitempty"`
+	Error       string     `json:"error,omitempty"`
+}
+
+type backend struct {
+	url         string
+	client      *ollama.Client
+	healthy     bool
+	inFlight    int
+	models      map[string]bool
+	lastChecked time.Time
+	lastErr     string
+}
+
+type Pool struct {
+	mu       sync.Mutex
+	backends []*backend
+	balance  string
+	next     int
+}
+
+func NewPool(urls []string, balance string) *Pool {
+	p := &Pool{balance: balance}
+	for _, u := range urls {
+		p.backends = append(p.backends, &backend{
+			url:     u,
+			client:  ollama.New(u),
+			healthy: true,
+		})
+	}
+	return p
+}
+
+func modelKey(name string) string {
+	if !strings.Contains(name, ":") {
+		return name + ":latest"
+	}
+	return name
+}
+
+func (p *Pool) check(ctx context.Context, b *backend) ([]ollama.Model, error) {
+	ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+	defer cancel()
+	models, err := b.client.ListModels(ctx)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	b.lastChecked = time.Now()
+	if err != nil {
+		b.healthy = false
+		b.lastErr = err.Error()
+		return nil, err
+	}
+	b.healthy = true
+	b.lastErr = ""
+	b.models = make(map[string]bool, len(models))
+	for _, m := range models {
+		b.models[m.Name] = true
+	}
+	return models, nil
+}
+
+func (p *Pool) checkAll(ctx context.Context) ([][]ollama.Model, []error) {
+	models := make([][]ollama.Model, len(p.backends))
+	errs := make([]error, len(p.backends))
+	var wg sync.WaitGroup
+	for i, b := range p.backends {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			models[i], errs[i] = p.check(ctx, b)
+		}()
+	}
+	wg.Wait()
+	return models, errs
+}
+
+func (p *Pool) Refresh(ctx context.Context) {
+	p.checkAll(ctx)
+}
+
+func (p *Pool) Backends() []BackendStatus {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	out := make([]BackendStatus, 0, len(p.backends))
+	for _, b := range p.backends {
+		s := BackendStatus{
+			URL:      b.url,
+			Healthy:  b.healthy,
+			InFlight: b.inFlight,
+			Models:   make([]string, 0, len(b.models)),
+			Error:    b.lastErr,
+		}
+		if !b.lastChecked.IsZero() {
+			checked := b.lastChecked
+			s.LastChecked = &checked
+		}
+		for name := range b.models {
+			s.Models = append(s.Models, name)
+		}
+		sort.Strings(s.Models)
+		out = append(out, s)
+	}
+	return out
+}
+
+func (p *Pool) candidates(model string) []*backend {
+	key := modelKey(model)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var withModel, healthy []*backend
+	for _, b := range p.backends {
+		if !b.healthy {
+			continue
+		}
+		healthy = append(healthy, b)
+		if b.models[key] {
+			withModel = append(withModel, b)
+		}
+	}
+	list := withModel
+	if len(list) == 0 {
+		list = healthy
+	}
+	if len(list) == 0 {
+		list = p.backends
+	}
+
+	start := p.next % len(list)
+	p.next++
+	ordered := make([]*backend, 0, len(list))
+	ordered = append(ordered, list[start:]...)
+	ordered = append(ordered, list[:start]...)
+	if p.balance != BalanceRoundRobin {
+		sort.SliceStable(ordered, func(i, j int) bool { return ordered[i].inFlight < ordered[j].inFlight })
+	}
+	return ordered
+}
+
+func (p *Pool) acquire(b *backend) {
+	p.mu.Lock()
+	b.inFlight++
+	p.mu.Unlock()
+}
+
+func (p *Pool) release(b *backend) {
+	p.mu.Lock()
+	b.inFlight--
+	p.mu.Unlock()
+}
+
+func (p *Pool) failed(b *backend, model string, err error) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		b.healthy = false
+		b.lastErr = err.Error()
+		return true
+	}
+	var statusErr *ollama.StatusError
+	if errors.As(err, &statusErr) {
+		switch {
+		case statusErr.StatusCode == http.StatusNotFound:
+			delete(b.models, modelKey(model))
+			return true
+		case statusErr.StatusCode >= 500:
+			return true
+		}
+	}
+	return false
+}
+
+func (p *Pool) ListModels(ctx context.Context) ([]ollama.Model, error) {
+	results, errs := p.checkAll(ctx)
+
+	seen := make(map[string]bool)
+	var all []ollama.Model
+	var failures []error
+	for i, models := range results {
+		if errs[i] != nil {
+			failures = append(failures, fmt.Errorf("%s: %w", p.backends[i].url, errs[i]))
+			continue
+		}
+		for _, m := range models {
+			if seen[m.Name] {
+				continue
+			}
+			seen[m.Name] = true
+			all = append(all, m)
+		}
+	}
+	if len(failures) == len(p.backends) {
+		return nil, errors.Join(failures...)
+	}
+	return all, nil
+}
+
+func (p *Pool) Chat(ctx context.Context, req *ollama.ChatRequest) (*ollama.ChatResponse, error) {
+	var lastErr error
+	for _, b := range p.candidates(req.Model) {
+		p.acquire(b)
+		resp, err := b.client.Chat(ctx, req)
+		p.release(b)
+		if err == nil || ctx.Err() != nil {
+			return resp, err
+		}
+		if !p.failed(b, req.Model, err) {
+			return nil, err
+		}
+		lastErr = err
+	}
+	return nil, lastErr
+}
+
+func (p *Pool) ChatStream(ctx context.Context, req *ollama.ChatRequest, onChunk func(ollama.ChatResponse) error) error {
+	var lastErr error
+	for _, b := range p.candidates(req.Model) {
+		started := false
+		p.acquire(b)
+		err := b.client.ChatStream(ctx, req, func(resp ollama.ChatResponse) error {
+			started = true
+			return onChunk(resp)
+		})
+		p.release(b)
+		if err == nil || ctx.Err() != nil {
+			return err
+		}
+		if !p.failed(b, req.Model, err) || started {
+			return err
+		}
+		lastErr = err
+	}
+	return lastErr
+}
+
+func (p *Pool) IsHealthy(ctx context.Context) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, b := range p.backends {
+		if b.healthy {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *Pool) ShowModel(ctx context.Context, name string) (*ollama.ShowResponse, error) {
+	var lastErr error
+	for _, b := range p.candidates(name) {
+		show, err := b.client.ShowModel(ctx, name)
+		if err == nil || ctx.Err() != nil {
+			return show, err
+		}
+		if !p.failed(b, name, err) {
+			return nil, err
+		}
+		lastErr = err
+	}
+	return nil, lastErr
+}
+
+func (p *Pool) healthyBackends() []*backend {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var out []*backend
+	for _, b := range p.backends {
+		if b.healthy {
+			out = append(out, b)
+		}
+	}
+	return out
+}
+
+func (p *Pool) PullModel(ctx context.Context, name string, onProgress func(ollama.PullResponse) error) error {
+	targets := p.healthyBackends()
+	if len(targets) == 0 {
+		return errors.New("no healthy ollama backends")
+	}
+	for _, b := range targets {
+		if err := b.client.PullModel(ctx, name, onProgress); err != nil {
+			return fmt.Errorf("%s: %w", b.url, err)
+		}
+		p.check(ctx, b)
+	}
+	return nil
+}
+
+func (p *Pool) DeleteModel(ctx context.Context, name string) error {
+	key := modelKey(name)
+	var targets []*backend
+	for _, b := range p.healthyBackends() {
+		p.mu.Lock()
+		has := b.models[key]
+		p.mu.Unlock()
+		if has {
+			targets = append(targets, b)
+		}
+	}
+	untracked := len(targets) == 0
+	if untracked {
+		targets = p.healthyBackends()
+	}
+	if len(targets) == 0 {
+		return errors.New("no healthy ollama backends")
+	}
+
+	var errs, missing []error
+	for _, b := range targets {
+		if err := b.client.DeleteModel(ctx, name); err != nil {
+			var statusErr *ollama.StatusError
+			if untracked && errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusNotFound {
+				missing = append(missing, fmt.Errorf("%s: %w", b.url, err))
+				continue
+			}
+			errs = append(errs, fmt.Errorf("%s: %w", b.url, err))
+			continue
+		}
+		p.mu.Lock()
+		delete(b.models, key)
+		p.mu.Unlock()
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+	if len(missing) == len(targets) {
+		return errors.Join(missing...)
+	}
+	return nil
+}
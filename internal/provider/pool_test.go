@@ -0,0 +1,170 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/ifauzeee/Zee-AI/internal/ollama"
+)
+
+type fakeOllama struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	requests []ollama.ChatRequest
+}
+
+func newFakeOllama(t *testing.T, reply string) *fakeOllama {
+	t.Helper()
+
+	f := &fakeOllama{}
+	f.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/tags":
+			fmt.Fprint(w, `{"models":[{"name":"llama3:latest","modified_at":"2024-01-01T00:00:00Z"}]}`)
+		case "/api/chat":
+			var req ollama.ChatRequest
+			json.NewDecoder(r.Body).Decode(&req)
+			f.mu.Lock()
+			f.requests = append(f.requests, req)
+			f.mu.Unlock()
+			if !req.Stream {
+				fmt.Fprintf(w, `{"message":{"role":"assistant","content":%q},"done":true}`, reply)
+				return
+			}
+			fmt.Fprintf(w, `{"message":{"role":"assistant","content":%q},"done":true}`+"\n", reply)
+		case "/api/delete":
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(f.Close)
+	return f
+}
+
+func (f *fakeOllama) chats() []ollama.ChatRequest {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]ollama.ChatRequest(nil), f.requests...)
+}
+
+func chatRequest() *ollama.ChatRequest {
+	return &ollama.ChatRequest{Model: "llama3", Messages: []ollama.ChatMessage{{Role: "user", Content: "hi"}}}
+}
+
+func TestPoolFailover(t *testing.T) {
+	first := newFakeOllama(t, "from first")
+	second := newFakeOllama(t, "from second")
+	pool := NewPool([]string{first.URL, second.URL, "http://127.0.0.1:0"}, BalanceRoundRobin)
+	pool.Refresh(context.Background())
+
+	if backends := pool.Backends(); len(backends) != 3 || !backends[0].Healthy || backends[2].Healthy {
+		t.Fatalf("backends = %+v", backends)
+	}
+	models, err := pool.ListModels(context.Background())
+	if err != nil || len(models) != 1 || models[0].Name != "llama3:latest" {
+		t.Fatalf("models = %+v, %v", models, err)
+	}
+
+	first.Close()
+	for i := 0; i < 2; i++ {
+		resp, err := pool.Chat(context.Background(), chatRequest())
+		if err != nil || resp.Message.Content != "from second" {
+			t.Fatalf("chat %d = %+v, %v", i, resp, err)
+		}
+	}
+	if chats := second.chats(); len(chats) != 2 || chats[0].Model != "llama3" {
+		t.Fatalf("second backend requests = %+v", chats)
+	}
+	if backends := pool.Backends(); backends[0].Healthy || !backends[1].Healthy {
+		t.Fatalf("backends = %+v", backends)
+	}
+}
+
+func TestPoolClientErrors(t *testing.T) {
+	failing := func(status int, body string) (*httptest.Server, *int) {
+		var mu sync.Mutex
+		chats := 0
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/api/tags":
+				fmt.Fprint(w, `{"models":[{"name":"llama3:latest","modified_at":"2024-01-01T00:00:00Z"}]}`)
+			case "/api/chat":
+				mu.Lock()
+				chats++
+				mu.Unlock()
+				w.WriteHeader(status)
+				fmt.Fprint(w, body)
+			case "/api/delete":
+				w.WriteHeader(status)
+				fmt.Fprint(w, body)
+			default:
+				http.NotFound(w, r)
+			}
+		}))
+		t.Cleanup(srv.Close)
+		return srv, &chats
+	}
+
+	invalid, _ := failing(http.StatusBadRequest, `{"error":"invalid options"}`)
+	ok := newFakeOllama(t, "fine")
+	pool := NewPool([]string{invalid.URL, ok.URL}, BalanceRoundRobin)
+	pool.Refresh(context.Background())
+
+	_, err := pool.Chat(context.Background(), chatRequest())
+	var statusErr *ollama.StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusBadRequest {
+		t.Fatalf("chat error = %v, want the 400 from the first backend", err)
+	}
+	if n := len(ok.chats()); n != 0 {
+		t.Fatalf("a 400 failed over to another backend (%d requests)", n)
+	}
+	if backends := pool.Backends(); !backends[0].Healthy || len(backends[0].Models) != 1 {
+		t.Fatalf("a 400 penalized the backend: %+v", backends[0])
+	}
+
+	if err := pool.DeleteModel(context.Background(), "llama3"); err == nil || !strings.Contains(err.Error(), invalid.URL) {
+		t.Fatalf("partial delete error = %v, want the failing backend reported", err)
+	}
+	if backends := pool.Backends(); len(backends[0].Models) != 1 || len(backends[1].Models) != 0 {
+		t.Fatalf("after partial delete = %+v", backends)
+	}
+
+	malformed, malformedChats := failing(http.StatusOK, `{"message": not json`)
+	ok = newFakeOllama(t, "fine")
+	pool = NewPool([]string{malformed.URL, ok.URL}, BalanceRoundRobin)
+	pool.Refresh(context.Background())
+	if _, err := pool.Chat(context.Background(), chatRequest()); err == nil {
+		t.Fatal("malformed reply was accepted")
+	}
+	if n := len(ok.chats()); n != 0 || *malformedChats != 1 {
+		t.Fatalf("malformed reply failed over: %d fallback requests", n)
+	}
+	if backends := pool.Backends(); !backends[0].Healthy {
+		t.Fatalf("malformed reply marked the backend down: %+v", backends[0])
+	}
+
+	missing, missingChats := failing(http.StatusNotFound, `{"error":"model 'llama3' not found"}`)
+	ok = newFakeOllama(t, "fine")
+	pool = NewPool([]string{missing.URL, ok.URL}, BalanceRoundRobin)
+	pool.Refresh(context.Background())
+
+	for i := 0; i < 2; i++ {
+		if err := pool.ChatStream(context.Background(), chatRequest(), func(ollama.ChatResponse) error { return nil }); err != nil {
+			t.Fatalf("chat %d: %v", i, err)
+		}
+	}
+	if n := len(ok.chats()); n != 2 || *missingChats != 1 {
+		t.Fatalf("requests = %d to the fallback and %d to the missing backend, want 2 and 1", n, *missingChats)
+	}
+	if backends := pool.Backends(); !backends[0].Healthy || len(backends[0].Models) != 0 {
+		t.Fatalf("model not found should only drop the model: %+v", backends[0])
+	}
+}
@@ -0,0 +1,76 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/ifauzeee/Zee-AI/internal/ollama"
+)
+
+func GenerateTitle(ctx context.Context, p Provider, model, userMessage string) (string, error) {
+	req := &ollama.ChatRequest{
+		Model: model,
+		Messages: []ollama.ChatMessage{
+			{
+				Role:    "system",
+				Content: "Generate a very short title (max 6 words) for a conversation that starts with the following message. Reply with ONLY the title, no quotes, no punctuation at the end.",
+			},
+			{
+				Role:    "user",
+				Content: userMessage,
+			},
+		},
+		Stream: false,
+		Options: &ollama.Options{
+			Temperature: ollama.Ptr(0.3),
+			NumPredict:  ollama.Ptr(20),
+		},
+	}
+
+	resp, err := p.Chat(ctx, req)
+	if err != nil {
+		return "", err
+	}
+
+	title := resp.Message.Content
+	if len(title) > 80 {
+		title = title[:80]
+	}
+	return title, nil
+}
+
+func Summarize(ctx context.Context, p Provider, model, previousSummary string, messages []ollama.ChatMessage) (string, error) {
+	var transcript strings.Builder
+	if previousSummary != "" {
+		fmt.Fprintf(&transcript, "summary of everything before: %s\n\n", previousSummary)
+	}
+	for _, m := range messages {
+		fmt.Fprintf(&transcript, "%s: %s\n\n", m.Role, m.Content)
+	}
+
+	req := &ollama.ChatRequest{
+		Model: model,
+		Messages: []ollama.ChatMessage{
+			{
+				Role:    "system",
+				Content: "Summarize the following conversation so it can replace the original messages as context. Keep facts, decisions, names, numbers and open questions. Be concise and write in the third person. Reply with ONLY the summary.",
+			},
+			{
+				Role:    "user",
+				Content: transcript.String(),
+			},
+		},
+		Stream: false,
+		Options: &ollama.Options{
+			Temperature: ollama.Ptr(0.2),
+			NumPredict:  ollama.Ptr(400),
+		},
+	}
+
+	resp, err := p.Chat(ctx, req)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(resp.Message.Content), nil
+}
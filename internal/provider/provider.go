@@ -0,0 +1,45 @@
+package provider
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ifauzeee/Zee-AI/internal/ollama"
+)
+
+var ErrUnsupported = errors.New("operation not supported by this provider")
+
+type Provider interface {
+	ListModels(ctx context.Context) ([]ollama.Model, error)
+	Chat(ctx context.Context, req *ollama.ChatRequest) (*ollama.ChatResponse, error)
+	ChatStream(ctx context.Context, req *ollama.ChatRequest, onChunk func(ollama.ChatResponse) error) error
+	IsHealthy(ctx context.Context) bool
+}
+
+type ModelManager interface {
+	PullModel(ctx context.Context, name string, onProgress func(ollama.PullResponse) error) error
+	DeleteModel(ctx context.Context, name string) error
+}
+
+type ModelInspector interface {
+	ShowModel(ctx context.Context, name string) (*ollama.ShowResponse, error)
+}
+
+type HealthReporter interface {
+	Health(ctx context.Context) map[string]bool
+}
+
+type BackendReporter interface {
+	Backends() []BackendStatus
+}
+
+var (
+	_ Provider       = (*ollama.Client)(nil)
+	_ ModelManager   = (*ollama.Client)(nil)
+	_ ModelInspector = (*ollama.Client)(nil)
+
+	_ Provider        = (*Pool)(nil)
+	_ ModelManager    = (*Pool)(nil)
+	_ ModelInspector  = (*Pool)(nil)
+	_ BackendReporter = (*Pool)(nil)
+)
@@ -0,0 +1,163 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/ifauzeee/Zee-AI/internal/ollama"
+)
+
+const Separator = "/"
+
+type Router struct {
+	defaultName string
+	providers   map[string]Provider
+}
+
+func NewRouter(defaultName string, p Provider) *Router {
+	return &Router{
+		defaultName: defaultName,
+		providers:   map[string]Provider{defaultName: p},
+	}
+}
+
+func (r *Router) Register(name string, p Provider) {
+	r.providers[name] = p
+}
+
+func (r *Router) Names() []string {
+	names := make([]string, 0, len(r.providers))
+	for name := range r.providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func Shadowed(name string, models []string) bool {
+	for _, m := range models {
+		if prefix, _, ok := strings.Cut(m, Separator); ok && prefix == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Router) Resolve(model string) (Provider, string) {
+	if prefix, name, ok := strings.Cut(model, Separator); ok && prefix != r.defaultName {
+		if p, ok := r.providers[prefix]; ok {
+			return p, name
+		}
+	}
+	return r.providers[r.defaultName], model
+}
+
+func (r *Router) resolveRequest(req *ollama.ChatRequest) (Provider, *ollama.ChatRequest) {
+	p, model := r.Resolve(req.Model)
+	routed := *req
+	routed.Model = model
+	return p, &routed
+}
+
+func (r *Router) ListModels(ctx context.Context) ([]ollama.Model, error) {
+	type result struct {
+		models []ollama.Model
+		err    error
+	}
+	names := r.Names()
+	results := make([]result, len(names))
+
+	var wg sync.WaitGroup
+	for i, name := range names {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			models, err := r.providers[name].ListModels(ctx)
+			if err == nil && name != r.defaultName {
+				for j := range models {
+					models[j].Name = name + Separator + models[j].Name
+					models[j].Model = models[j].Name
+				}
+			}
+			results[i] = result{models, err}
+		}()
+	}
+	wg.Wait()
+
+	var all []ollama.Model
+	var errs []error
+	for i, res := range results {
+		if res.err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", names[i], res.err))
+			continue
+		}
+		all = append(all, res.models...)
+	}
+	if len(errs) == len(names) {
+		return nil, errors.Join(errs...)
+	}
+	return all, nil
+}
+
+func (r *Router) Chat(ctx context.Context, req *ollama.ChatRequest) (*ollama.ChatResponse, error) {
+	p, routed := r.resolveRequest(req)
+	return p.Chat(ctx, routed)
+}
+
+func (r *Router) ChatStream(ctx context.Context, req *ollama.ChatRequest, onChunk func(ollama.ChatResponse) error) error {
+	p, routed := r.resolveRequest(req)
+	return p.ChatStream(ctx, routed, onChunk)
+}
+
+func (r *Router) IsHealthy(ctx context.Context) bool {
+	return r.providers[r.defaultName].IsHealthy(ctx)
+}
+
+func (r *Router) Health(ctx context.Context) map[string]bool {
+	health := make(map[string]bool, len(r.providers))
+	for name, p := range r.providers {
+		health[name] = p.IsHealthy(ctx)
+	}
+	return health
+}
+
+func (r *Router) Backends() []BackendStatus {
+	var out []BackendStatus
+	for _, name := range r.Names() {
+		if reporter, ok := r.providers[name].(BackendReporter); ok {
+			out = append(out, reporter.Backends()...)
+		}
+	}
+	return out
+}
+
+func (r *Router) PullModel(ctx context.Context, name string, onProgress func(ollama.PullResponse) error) error {
+	p, model := r.Resolve(name)
+	m, ok := p.(ModelManager)
+	if !ok {
+		return ErrUnsupported
+	}
+	return m.PullModel(ctx, model, onProgress)
+}
+
+func (r *Router) DeleteModel(ctx context.Context, name string) error {
+	p, model := r.Resolve(name)
+	m, ok := p.(ModelManager)
+	if !ok {
+		return ErrUnsupported
+	}
+	return m.DeleteModel(ctx, model)
+}
+
+func (r *Router) ShowModel(ctx context.Context, name string) (*ollama.ShowResponse, error) {
+	p, model := r.Resolve(name)
+	m, ok := p.(ModelInspector)
+	if !ok {
+		return nil, ErrUnsupported
+	}
+	return m.ShowModel(ctx, model)
+}
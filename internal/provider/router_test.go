@@ -0,0 +1,103 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/ifauzeee/Zee-AI/internal/ollama"
+)
+
+func TestRouter(t *testing.T) {
+	var mu sync.Mutex
+	var auth string
+	var models []interface{}
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		auth = r.Header.Get("Authorization")
+		mu.Unlock()
+		switch r.URL.Path {
+		case "/v1/models":
+			fmt.Fprint(w, `{"object":"list","data":[{"id":"qwen2.5-7b","created":1700000000}]}`)
+		case "/v1/chat/completions":
+			var req map[string]interface{}
+			json.NewDecoder(r.Body).Decode(&req)
+			mu.Lock()
+			models = append(models, req["model"])
+			mu.Unlock()
+			w.Header().Set("Content-Type", "text/event-stream")
+			for _, word := range []string{"Hi ", "there"} {
+				fmt.Fprintf(w, "data: {\"choices\":[{\"delta\":{\"content\":%q}}]}\n\n", word)
+			}
+			fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{},\"finish_reason\":\"stop\"}]}\n\n")
+			fmt.Fprint(w, "data: {\"choices\":[],\"usage\":{\"prompt_tokens\":4,\"completion_tokens\":2}}\n\n")
+			fmt.Fprint(w, "data: [DONE]\n\n")
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(backend.Close)
+	fake := newFakeOllama(t, "from ollama")
+
+	router := NewRouter("ollama", ollama.New(fake.URL))
+	router.Register("lmstudio", NewOpenAI(backend.URL+"/v1", "sk-test"))
+
+	listed, err := router.ListModels(context.Background())
+	names := map[string]bool{}
+	for _, m := range listed {
+		names[m.Name] = true
+	}
+	if err != nil || len(listed) != 2 || !names["lmstudio/qwen2.5-7b"] || !names["llama3:latest"] {
+		t.Fatalf("models = %+v, %v", listed, err)
+	}
+	mu.Lock()
+	if auth != "Bearer sk-test" {
+		t.Fatalf("authorization = %q", auth)
+	}
+	mu.Unlock()
+
+	var reply string
+	req := &ollama.ChatRequest{Model: "lmstudio/qwen2.5-7b", Messages: []ollama.ChatMessage{{Role: "user", Content: "hello"}}}
+	err = router.ChatStream(context.Background(), req, func(chunk ollama.ChatResponse) error {
+		reply += chunk.Message.Content
+		return nil
+	})
+	if err != nil || reply != "Hi there" || req.Model != "lmstudio/qwen2.5-7b" {
+		t.Fatalf("chat = %q, %v (request model %q)", reply, err, req.Model)
+	}
+	mu.Lock()
+	if len(models) != 1 || models[0] != "qwen2.5-7b" {
+		t.Fatalf("backend models = %v", models)
+	}
+	mu.Unlock()
+
+	for _, model := range []string{"llama3:latest", "library/llama3:latest", "ollama/llama3"} {
+		if p, name := router.Resolve(model); p != router.providers["ollama"] || name != model {
+			t.Errorf("Resolve(%q) = %T %q, want ollama with the name unchanged", model, p, name)
+		}
+	}
+	if _, err := router.Chat(context.Background(), &ollama.ChatRequest{Model: "llama3:latest"}); err != nil {
+		t.Fatalf("ollama chat: %v", err)
+	}
+	if chats := fake.chats(); len(chats) != 1 || chats[0].Model != "llama3:latest" {
+		t.Fatalf("ollama requests = %+v", chats)
+	}
+
+	if err := router.DeleteModel(context.Background(), "lmstudio/qwen2.5-7b"); !errors.Is(err, ErrUnsupported) {
+		t.Fatalf("delete = %v, want ErrUnsupported", err)
+	}
+}
+
+func TestShadowed(t *testing.T) {
+	installed := []string{"llama3:latest", "lmstudio/qwen:7b", "hf.co/org/model:q4"}
+	for name, want := range map[string]bool{"lmstudio": true, "hf.co": true, "llama3": false, "vllm": false} {
+		if got := Shadowed(name, installed); got != want {
+			t.Errorf("Shadowed(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
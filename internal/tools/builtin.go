@@ -0,0 +1,135 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ifauzeee/Zee-AI/internal/db"
+)
+
+const maxSearchResults = 20
+
+func Builtin(database *db.DB) *Registry {
+	r := NewRegistry()
+	for _, t := range []Tool{calculatorTool(), currentTimeTool(), conversationSearchTool(database)} {
+		if err := r.Register(t); err != nil {
+			panic(err)
+		}
+	}
+	return r
+}
+
+func calculatorTool() Tool {
+	return Tool{
+		Name:        "calculator",
+		Description: "Evaluate an arithmetic expression. Supports + - * / % ^, parentheses, pi, e and the functions sqrt, abs, sin, cos, tan, asin, acos, atan, ln, log, log2, exp, floor, ceil and round.",
+		Parameters: json.RawMessage(`{
+			"type": "object",
+			"properties": {
+				"expression": {"type": "string", "description": "The expression to evaluate, e.g. (3 + 4) * 2 ^ 3"}
+			},
+			"required": ["expression"]
+		}`),
+		Run: func(ctx context.Context, inv Invocation) (string, error) {
+			expr := inv.String("expression")
+			if strings.TrimSpace(expr) == "" {
+				return "", errors.New("expression is required")
+			}
+			v, err := Evaluate(expr)
+			if err != nil {
+				return "", err
+			}
+			return strconv.FormatFloat(v, 'g', 15, 64), nil
+		},
+	}
+}
+
+func currentTimeTool() Tool {
+	return Tool{
+		Name:        "current_time",
+		Description: "Get the current date and time, optionally in a given IANA time zone.",
+		Parameters: json.RawMessage(`{
+			"type": "object",
+			"properties": {
+				"timezone": {"type": "string", "description": "IANA time zone name such as Asia/Jakarta or UTC. Defaults to the server's local time."}
+			}
+		}`),
+		Run: func(ctx context.Context, inv Invocation) (string, error) {
+			loc := time.Local
+			if name := inv.String("timezone"); name != "" {
+				var err error
+				if loc, err = time.LoadLocation(name); err != nil {
+					return "", errors.New("unknown time zone " + strconv.Quote(name))
+				}
+			}
+			now := time.Now().In(loc)
+			out, _ := json.Marshal(map[string]string{
+				"time":     now.Format(time.RFC3339),
+				"timezone": loc.String(),
+				"weekday":  now.Weekday().String(),
+			})
+			return string(out), nil
+		},
+	}
+}
+
+func conversationSearchTool(database *db.DB) Tool {
+	return Tool{
+		Name:        "search_conversations",
+		Description: "Full-text search the user's past conversations. Returns matching conversation titles with message snippets.",
+		Parameters: json.RawMessage(`{
+			"type": "object",
+			"properties": {
+				"query": {"type": "string", "description": "Words to search for"},
+				"limit": {"type": "integer", "description": "Maximum number of conversations to return (default 5, max 20)"}
+			},
+			"required": ["query"]
+		}`),
+		Run: func(ctx context.Context, inv Invocation) (string, error) {
+			query := strings.TrimSpace(inv.String("query"))
+			if query == "" {
+				return "", errors.New("query is required")
+			}
+			limit := min(max(inv.Int("limit", 5), 1), maxSearchResults)
+
+			results, err := database.Search(inv.UserID, db.SearchOptions{Query: query, Limit: limit})
+			if err != nil {
+				return "", err
+			}
+
+			type match struct {
+				Role    string `json:"role"`
+				Snippet string `json:"snippet"`
+			}
+			type hit struct {
+				ConversationID string  `json:"conversation_id"`
+				Title          string  `json:"title"`
+				UpdatedAt      string  `json:"updated_at"`
+				Matches        []match `json:"matches"`
+			}
+			strip := strings.NewReplacer("<mark>", "", "</mark>", "")
+			hits := make([]hit, 0, len(results))
+			for _, r := range results {
+				if r.Conversation.ID == inv.ConversationID {
+					continue
+				}
+				h := hit{
+					ConversationID: r.Conversation.ID,
+					Title:          r.Conversation.Title,
+					UpdatedAt:      r.Conversation.UpdatedAt.Format(time.RFC3339),
+					Matches:        []match{},
+				}
+				for _, m := range r.Matches {
+					h.Matches = append(h.Matches, match{Role: m.Role, Snippet: strip.Replace(m.Snippet)})
+				}
+				hits = append(hits, h)
+			}
+			out, _ := json.Marshal(hits)
+			return string(out), nil
+		},
+	}
+}
@@ -0,0 +1,223 @@
+package tools
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+var calcFunctions = map[string]func(float64) float64{
+	"sqrt":  math.Sqrt,
+	"abs":   math.Abs,
+	"sin":   math.Sin,
+	"cos":   math.Cos,
+	"tan":   math.Tan,
+	"asin":  math.Asin,
+	"acos":  math.Acos,
+	"atan":  math.Atan,
+	"ln":    math.Log,
+	"log":   math.Log10,
+	"log2":  math.Log2,
+	"exp":   math.Exp,
+	"floor": math.Floor,
+	"ceil":  math.Ceil,
+	"round": math.Round,
+}
+
+var calcConstants = map[string]float64{
+	"pi": math.Pi,
+	"e":  math.E,
+}
+
+type calcParser struct {
+	src string
+	pos int
+}
+
+func Evaluate(expr string) (float64, error) {
+	p := &calcParser{src: expr}
+	v, err := p.expression()
+	if err != nil {
+		return 0, err
+	}
+	p.skipSpace()
+	if p.pos < len(p.src) {
+		return 0, fmt.Errorf("unexpected %q at position %d", p.src[p.pos], p.pos+1)
+	}
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return 0, errors.New("result is not a finite number")
+	}
+	return v, nil
+}
+
+func (p *calcParser) skipSpace() {
+	for p.pos < len(p.src) && unicode.IsSpace(rune(p.src[p.pos])) {
+		p.pos++
+	}
+}
+
+func (p *calcParser) peek() byte {
+	p.skipSpace()
+	if p.pos < len(p.src) {
+		return p.src[p.pos]
+	}
+	return 0
+}
+
+func (p *calcParser) expression() (float64, error) {
+	left, err := p.term()
+	if err != nil {
+		return 0, err
+	}
+	for {
+		op := p.peek()
+		if op != '+' && op != '-' {
+			return left, nil
+		}
+		p.pos++
+		right, err := p.term()
+		if err != nil {
+			return 0, err
+		}
+		if op == '+' {
+			left += right
+		} else {
+			left -= right
+		}
+	}
+}
+
+func (p *calcParser) term() (float64, error) {
+	left, err := p.unary()
+	if err != nil {
+		return 0, err
+	}
+	for {
+		op := p.peek()
+		if op != '*' && op != '/' && op != '%' {
+			return left, nil
+		}
+		p.pos++
+		right, err := p.unary()
+		if err != nil {
+			return 0, err
+		}
+		switch {
+		case op == '*':
+			left *= right
+		case right == 0:
+			return 0, errors.New("division by zero")
+		case op == '/':
+			left /= right
+		default:
+			left = math.Mod(left, right)
+		}
+	}
+}
+
+func (p *calcParser) unary() (float64, error) {
+	switch p.peek() {
+	case '-':
+		p.pos++
+		v, err := p.unary()
+		return -v, err
+	case '+':
+		p.pos++
+		return p.unary()
+	}
+	return p.power()
+}
+
+func (p *calcParser) power() (float64, error) {
+	base, err := p.primary()
+	if err != nil {
+		return 0, err
+	}
+	if p.peek() != '^' {
+		return base, nil
+	}
+	p.pos++
+	exp, err := p.unary()
+	if err != nil {
+		return 0, err
+	}
+	return math.Pow(base, exp), nil
+}
+
+func (p *calcParser) primary() (float64, error) {
+	c := p.peek()
+	switch {
+	case c == '(':
+		p.pos++
+		v, err := p.expression()
+		if err != nil {
+			return 0, err
+		}
+		if p.peek() != ')' {
+			return 0, errors.New("missing closing parenthesis")
+		}
+		p.pos++
+		return v, nil
+	case c == '.' || (c >= '0' && c <= '9'):
+		return p.number()
+	case unicode.IsLetter(rune(c)):
+		return p.identifier()
+	case c == 0:
+		return 0, errors.New("unexpected end of expression")
+	}
+	return 0, fmt.Errorf("unexpected %q at position %d", c, p.pos+1)
+}
+
+func (p *calcParser) number() (float64, error) {
+	start := p.pos
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		if (c >= '0' && c <= '9') || c == '.' {
+			p.pos++
+			continue
+		}
+		if (c == 'e' || c == 'E') && p.pos+1 < len(p.src) {
+			next := p.src[p.pos+1]
+			if next >= '0' && next <= '9' {
+				p.pos++
+				continue
+			}
+			if (next == '+' || next == '-') && p.pos+2 < len(p.src) && p.src[p.pos+2] >= '0' && p.src[p.pos+2] <= '9' {
+				p.pos += 2
+				continue
+			}
+		}
+		break
+	}
+	v, err := strconv.ParseFloat(p.src[start:p.pos], 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid number %q", p.src[start:p.pos])
+	}
+	return v, nil
+}
+
+func (p *calcParser) identifier() (float64, error) {
+	start := p.pos
+	for p.pos < len(p.src) && (unicode.IsLetter(rune(p.src[p.pos])) || unicode.IsDigit(rune(p.src[p.pos]))) {
+		p.pos++
+	}
+	name := strings.ToLower(p.src[start:p.pos])
+	if v, ok := calcConstants[name]; ok {
+		return v, nil
+	}
+	fn, ok := calcFunctions[name]
+	if !ok {
+		return 0, fmt.Errorf("unknown function or constant %q", name)
+	}
+	if p.peek() != '(' {
+		return 0, fmt.Errorf("%s must be followed by parentheses", name)
+	}
+	arg, err := p.primary()
+	if err != nil {
+		return 0, err
+	}
+	return fn(arg), nil
+}
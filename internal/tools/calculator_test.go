@@ -0,0 +1,25 @@
+package tools
+
+import "testing"
+
+func TestCalculator(t *testing.T) {
+	for expr, want := range map[string]float64{
+		"1 + 2 * 3":          7,
+		"(1 + 2) * 3":        9,
+		"-2 ^ 2":             -4,
+		"2 ^ 3 ^ 2":          512,
+		"sqrt(16) + abs(-1)": 5,
+		"10 % 4":             2,
+		"1.5e2 / 3":          50,
+	} {
+		got, err := Evaluate(expr)
+		if err != nil || got != want {
+			t.Errorf("Evaluate(%q) = %v, %v; want %v", expr, got, err, want)
+		}
+	}
+	for _, expr := range []string{"1 / 0", "2 +", "foo(1)", "(1 + 2"} {
+		if _, err := Evaluate(expr); err == nil {
+			t.Errorf("Evaluate(%q) succeeded, want error", expr)
+		}
+	}
+}
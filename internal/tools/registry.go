@@ -0,0 +1,123 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/ifauzeee/Zee-AI/internal/ollama"
+)
+
+var ErrUnknownTool = errors.New("unknown tool")
+
+type Invocation struct {
+	UserID         string
+	ConversationID string
+	Args           map[string]interface{}
+}
+
+func (inv Invocation) String(key string) string {
+	switch v := inv.Args[key].(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func (inv Invocation) Int(key string, fallback int) int {
+	switch v := inv.Args[key].(type) {
+	case float64:
+		return int(v)
+	case string:
+		if n, err := strconv.Atoi(v); err == nil {
+			return n
+		}
+	}
+	return fallback
+}
+
+type Func func(ctx context.Context, inv Invocation) (string, error)
+
+type Tool struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	Parameters  json.RawMessage `json:"parameters"`
+	Run         Func            `json:"-"`
+}
+
+type Registry struct {
+	mu    sync.RWMutex
+	tools map[string]Tool
+}
+
+func NewRegistry() *Registry {
+	return &Registry{tools: make(map[string]Tool)}
+}
+
+func (r *Registry) Register(t Tool) error {
+	if t.Name == "" || t.Run == nil {
+		return fmt.Errorf("tool must have a name and a function")
+	}
+	if !json.Valid(t.Parameters) {
+		return fmt.Errorf("tool %s: parameters must be a JSON schema", t.Name)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.tools[t.Name]; ok {
+		return fmt.Errorf("tool %s is already registered", t.Name)
+	}
+	r.tools[t.Name] = t
+	return nil
+}
+
+func (r *Registry) Get(name string) (Tool, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	t, ok := r.tools[name]
+	return t, ok
+}
+
+func (r *Registry) List() []Tool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := make([]Tool, 0, len(r.tools))
+	for _, t := range r.tools {
+		out = append(out, t)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+func (r *Registry) Definitions(names []string) ([]ollama.Tool, error) {
+	defs := make([]ollama.Tool, 0, len(names))
+	for _, name := range names {
+		t, ok := r.Get(name)
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownTool, name)
+		}
+		defs = append(defs, ollama.Tool{
+			Type: "function",
+			Function: ollama.ToolFunction{
+				Name:        t.Name,
+				Description: t.Description,
+				Parameters:  t.Parameters,
+			},
+		})
+	}
+	return defs, nil
+}
+
+func (r *Registry) Run(ctx context.Context, name string, inv Invocation) (string, error) {
+	t, ok := r.Get(name)
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrUnknownTool, name)
+	}
+	return t.Run(ctx, inv)
+}
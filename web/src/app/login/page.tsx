@@ -0,0 +1,54 @@
+'use client';
+
+import { useState } from 'react';
+import { LogIn } from 'lucide-react';
+import { login } from '@/lib/api';
+
+export default function LoginPage() {
+  const [username, setUsername] = useState('');
+  const [password, setPassword] = useState('');
+  const [error, setError] = useState('');
+  const [submitting, setSubmitting] = useState(false);
+
+  async function handleSubmit(e: React.FormEvent) {
+    e.preventDefault();
+    setSubmitting(true);
+    setError('');
+    try {
+      await login(username, password);
+      window.location.href = '/';
+    } catch (err) {
+      setError(err instanceof Error ? err.message : 'Failed to log in');
+      setSubmitting(false);
+    }
+  }
+
+  return (
+    <main className="h-screen flex items-center justify-center bg-grid-pattern px-4">
+      <form onSubmit={handleSubmit} className="glass-card p-8 w-full max-w-sm space-y-4">
+        <h1 className="text-2xl font-bold gradient-text text-center mb-2">Zee-AI</h1>
+        <input
+          value={username}
+          onChange={(e) => setUsername(e.target.value)}
+          placeholder="Username"
+          autoComplete="username"
+          autoFocus
+          className="w-full bg-[rgba(0,0,0,0.2)] border border-[rgba(255,255,255,0.08)] rounded-xl px-4 py-3 text-sm text-text-primary outline-none focus:border-[#8a2be2] focus:ring-1 focus:ring-[#8a2be2] transition-all"
+        />
+        <input
+          type="password"
+          value={password}
+          onChange={(e) => setPassword(e.target.value)}
+          placeholder="Password"
+          autoComplete="current-password"
+          className="w-full bg-[rgba(0,0,0,0.2)] border border-[rgba(255,255,255,0.08)] rounded-xl px-4 py-3 text-sm text-text-primary outline-none focus:border-[#8a2be2] focus:ring-1 focus:ring-[#8a2be2] transition-all"
+        />
+        {error && <p className="text-sm text-error">{error}</p>}
+        <button type="submit" disabled={submitting || !username || !password} className="btn-primary w-full">
+          <LogIn size={16} />
+          Sign in
+        </button>
+      </form>
+    </main>
+  );
+}
@@ -10,12 +10,15 @@ import {
     Cpu,
     Zap,
     MessagesSquare,
+    LogOut,
 } from 'lucide-react';
 import { useStore } from '@/lib/store';
 import {
     fetchConversations,
     fetchModels,
     deleteConversation,
+    getToken,
+    logout,
     timeAgo,
 } from '@/lib/api';
 
@@ -35,8 +38,10 @@ export default function Sidebar() {
     } = useStore();
 
     const [showModels, setShowModels] = useState(false);
+    const [signedIn, setSignedIn] = useState(false);
 
     useEffect(() => {
+        setSignedIn(getToken() !== null);
         loadData();
         const interval = setInterval(loadData, 5000);
         return () => clearInterval(interval);
@@ -243,6 +248,15 @@ export default function Sidebar() {
                                         ? `SYS: ONLINE · ${models.length} NODE${models.length > 1 ? 'S' : ''}`
                                         : 'SYS: OFFLINE'}
                                 </span>
+                                {signedIn && (
+                                    <button
+                                        onClick={() => logout()}
+                                        className="ml-auto p-1.5 rounded-lg hover:bg-[rgba(255,255,255,0.05)] text-text-tertiary hover:text-text-primary transition-all"
+                                        aria-label="Sign out"
+                                    >
+                                        <LogOut size={14} />
+                                    </button>
+                                )}
                             </div>
                         </div>
                     </motion.aside>
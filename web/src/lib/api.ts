@@ -1,5 +1,14 @@
 export const API_BASE = process.env.NEXT_PUBLIC_API_URL || 'http://localhost:8080';
 
+const TOKEN_KEY = 'zee-ai-token';
+
+export interface User {
+    id: string;
+    username: string;
+    role: 'admin' | 'member' | 'readonly';
+    created_at: string;
+}
+
 export interface Conversation {
     id: string;
     title: string;
@@ -46,22 +55,77 @@ export interface ChatStreamChunk {
     error?: string;
 }
 
+export function getToken(): string | null {
+    if (typeof window === 'undefined') return null;
+    return localStorage.getItem(TOKEN_KEY);
+}
+
+export function clearToken(): void {
+    localStorage.removeItem(TOKEN_KEY);
+}
+
+function redirectToLogin(): void {
+    clearToken();
+    if (window.location.pathname !== '/login') {
+        window.location.href = '/login';
+    }
+}
+
+async function apiFetch(path: string, init: RequestInit = {}): Promise<Response> {
+    const headers = new Headers(init.headers);
+    const token = getToken();
+    if (token) headers.set('Authorization', `Bearer ${token}`);
+
+    const res = await fetch(`${API_BASE}${path}`, { ...init, headers });
+    if (res.status === 401) redirectToLogin();
+    return res;
+}
+
+export async function login(username: string, password: string): Promise<User> {
+    const res = await fetch(`${API_BASE}/api/auth/login`, {
+        method: 'POST',
+        headers: { 'Content-Type': 'application/json' },
+        body: JSON.stringify({ username, password }),
+    });
+    const data = await res.json();
+    if (!res.ok) throw new Error(data.error || 'Failed to log in');
+    localStorage.setItem(TOKEN_KEY, data.token);
+    return data.user;
+}
+
+export async function logout(): Promise<void> {
+    await apiFetch('/api/auth/logout', { method: 'POST' });
+    redirectToLogin();
+}
+
 export async function fetchModels(): Promise<Model[]> {
-    const res = await fetch(`${API_BASE}/api/models`);
+    const res = await apiFetch('/api/models');
     if (!res.ok) throw new Error('Failed to fetch models');
     const data = await res.json();
     return data.models || [];
 }
 
+async function fetchAllPages<T>(path: string, key: string, errorMessage: string): Promise<T[]> {
+    const items: T[] = [];
+    let cursor = '';
+    do {
+        const query = new URLSearchParams({ limit: '200' });
+        if (cursor) query.set('cursor', cursor);
+        const res = await apiFetch(`${path}?${query}`);
+        if (!res.ok) throw new Error(errorMessage);
+        const data = await res.json();
+        items.push(...(data[key] || []));
+        cursor = data.next_cursor || '';
+    } while (cursor);
+    return items;
+}
+
 export async function fetchConversations(): Promise<Conversation[]> {
-    const res = await fetch(`${API_BASE}/api/conversations`);
-    if (!res.ok) throw new Error('Failed to fetch conversations');
-    const data = await res.json();
-    return data.conversations || [];
+    return fetchAllPages<Conversation>('/api/conversations', 'conversations', 'Failed to fetch conversations');
 }
 
 export async function createConversation(model: string): Promise<Conversation> {
-    const res = await fetch(`${API_BASE}/api/conversations`, {
+    const res = await apiFetch('/api/conversations', {
         method: 'POST',
         headers: { 'Content-Type': 'application/json' },
         body: JSON.stringify({ model }),
@@ -71,17 +135,17 @@ export async function createConversation(model: string): Promise<Conversation> {
 }
 
 export async function fetchConversation(id: string): Promise<{ conversation: Conversation; messages: Message[] }> {
-    const res = await fetch(`${API_BASE}/api/conversations/${id}`);
+    const res = await apiFetch(`/api/conversations/${id}`);
     if (!res.ok) throw new Error('Failed to fetch conversation');
     return res.json();
 }
 
 export async function deleteConversation(id: string): Promise<void> {
-    await fetch(`${API_BASE}/api/conversations/${id}`, { method: 'DELETE' });
+    await apiFetch(`/api/conversations/${id}`, { method: 'DELETE' });
 }
 
 export async function updateConversationTitle(id: string, title: string): Promise<void> {
-    await fetch(`${API_BASE}/api/conversations/${id}`, {
+    await apiFetch(`/api/conversations/${id}`, {
         method: 'PATCH',
         headers: { 'Content-Type': 'application/json' },
         body: JSON.stringify({ title }),
@@ -89,20 +153,17 @@ export async function updateConversationTitle(id: string, title: string): Promis
 }
 
 export async function fetchMessages(conversationId: string): Promise<Message[]> {
-    const res = await fetch(`${API_BASE}/api/conversations/${conversationId}/messages`);
-    if (!res.ok) throw new Error('Failed to fetch messages');
-    const data = await res.json();
-    return data.messages || [];
+    return fetchAllPages<Message>(`/api/conversations/${conversationId}/messages`, 'messages', 'Failed to fetch messages');
 }
 
 export async function fetchStats(): Promise<Record<string, unknown>> {
-    const res = await fetch(`${API_BASE}/api/stats`);
+    const res = await apiFetch('/api/stats');
     if (!res.ok) throw new Error('Failed to fetch stats');
     return res.json();
 }
 
 export async function deleteModel(name: string): Promise<void> {
-    const res = await fetch(`${API_BASE}/api/models/${encodeURIComponent(name)}`, { method: 'DELETE' });
+    const res = await apiFetch(`/api/models/${encodeURIComponent(name)}`, { method: 'DELETE' });
     if (!res.ok) {
         const data = await res.json();
         throw new Error(data.error || 'Failed to delete model');
@@ -120,7 +181,7 @@ export function streamChat(
 ): AbortController {
     const controller = new AbortController();
 
-    fetch(`${API_BASE}/api/chat`, {
+    apiFetch('/api/chat', {
         method: 'POST',
         headers: { 'Content-Type': 'application/json' },
         body: JSON.stringify({